@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DedupHandler wraps a slog.Handler and suppresses records whose level and
+// message were already emitted within window, so a hot error loop doesn't
+// flood the log destination.
+type DedupHandler struct {
+	next   slog.Handler
+	window time.Duration
+
+	// mu and seen are shared by pointer with every handler WithAttrs/
+	// WithGroup derive from this one, so concurrent slog.With calls (one
+	// per request, typically) still guard the same dedup state instead of
+	// each getting its own zero-value mutex over a map they all alias.
+	mu   *sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewDedupHandler returns a handler that forwards to next but drops repeats
+// of the same message+level seen within window.
+func NewDedupHandler(next slog.Handler, window time.Duration) *DedupHandler {
+	return &DedupHandler{
+		next:   next,
+		window: window,
+		mu:     &sync.Mutex{},
+		seen:   make(map[string]time.Time),
+	}
+}
+
+func (h *DedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *DedupHandler) Handle(ctx context.Context, record slog.Record) error {
+	key := record.Level.String() + "|" + record.Message
+
+	h.mu.Lock()
+	last, ok := h.seen[key]
+	now := time.Now()
+	if ok && now.Sub(last) < h.window {
+		h.mu.Unlock()
+		return nil
+	}
+	h.seen[key] = now
+	h.mu.Unlock()
+
+	return h.next.Handle(ctx, record)
+}
+
+func (h *DedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &DedupHandler{next: h.next.WithAttrs(attrs), window: h.window, mu: h.mu, seen: h.seen}
+}
+
+func (h *DedupHandler) WithGroup(name string) slog.Handler {
+	return &DedupHandler{next: h.next.WithGroup(name), window: h.window, mu: h.mu, seen: h.seen}
+}
+
+// FanOutHandler forwards every record to all of its constituent handlers, so
+// a single *slog.Logger can write to, e.g., stdout and a log file at once.
+type FanOutHandler struct {
+	handlers []slog.Handler
+}
+
+// NewFanOutHandler builds a handler that dispatches to all of handlers.
+func NewFanOutHandler(handlers ...slog.Handler) *FanOutHandler {
+	return &FanOutHandler{handlers: handlers}
+}
+
+func (h *FanOutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *FanOutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var firstErr error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (h *FanOutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		next[i] = handler.WithAttrs(attrs)
+	}
+	return &FanOutHandler{handlers: next}
+}
+
+func (h *FanOutHandler) WithGroup(name string) slog.Handler {
+	next := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		next[i] = handler.WithGroup(name)
+	}
+	return &FanOutHandler{handlers: next}
+}
@@ -1,13 +1,75 @@
-// Package logger provides structured JSON logging utilities.
+// Package logger provides structured logging built on log/slog, replacing
+// the ad-hoc *log.Logger/log.Printf usage previously scattered across
+// server/main.go and the services packages.
 package logger
 
 import (
 	"io"
-	"log"
+	"log/slog"
+
+	"github.com/yinghanhung/prr-playground/internal/config"
+)
+
+// Format selects the slog handler used by New/NewFanOut.
+type Format string
+
+const (
+	// FormatJSON is suitable for file output consumed by a log shipper.
+	FormatJSON Format = "json"
+	// FormatText is suitable for human-readable stdout output (docker logs).
+	FormatText Format = "text"
 )
 
-// New creates a new logger that writes to the given output with the specified prefix.
-// The logger uses standard log flags for timestamp and file information.
-func New(output io.Writer, prefix string) *log.Logger {
-	return log.New(output, prefix, log.LstdFlags)
+// Options configures the logger returned by New/NewFanOut.
+type Options struct {
+	// Level sets the minimum level that will be logged. Defaults to slog.LevelInfo.
+	Level slog.Level
+	// Format selects FormatJSON (default) or FormatText.
+	Format Format
+	// AddSource includes the file:line of the log call when true.
+	AddSource bool
+}
+
+// New creates a slog.Logger that writes to w using opts.Format.
+func New(w io.Writer, opts Options) *slog.Logger {
+	return slog.New(newHandler(w, opts))
+}
+
+// NewFanOut creates a slog.Logger that writes every record to both stdout
+// and file, replacing the old pattern of maintaining two separate loggers
+// and logging to each by hand.
+func NewFanOut(stdout, file io.Writer, opts Options) *slog.Logger {
+	return slog.New(NewFanOutHandler(newHandler(stdout, opts), newHandler(file, opts)))
+}
+
+func newHandler(w io.Writer, opts Options) slog.Handler {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level, AddSource: opts.AddSource}
+	if opts.Format == FormatText {
+		return slog.NewTextHandler(w, handlerOpts)
+	}
+	return slog.NewJSONHandler(w, handlerOpts)
+}
+
+// LevelFromEnv reads LOG_LEVEL (debug|info|warn|error) via config.GetString,
+// defaulting to info when unset or unrecognized.
+func LevelFromEnv() slog.Level {
+	switch config.GetString("LOG_LEVEL", "info") {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// FormatFromEnv reads LOG_FORMAT (text|json) via config.GetString,
+// defaulting to json when unset or unrecognized.
+func FormatFromEnv() Format {
+	if config.GetString("LOG_FORMAT", "json") == "text" {
+		return FormatText
+	}
+	return FormatJSON
 }
@@ -0,0 +1,97 @@
+package retry
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Policy configures DoWithPolicy's exponential backoff.
+type Policy struct {
+	// InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration
+	// MaxDelay caps the computed delay, before jitter is applied. Zero means
+	// no cap.
+	MaxDelay time.Duration
+	// Multiplier is the exponential growth factor applied per attempt.
+	Multiplier float64
+	// Jitter is a fraction (e.g. 0.2 for ±20%) applied to randomize each
+	// delay and avoid thundering-herd retries.
+	Jitter float64
+}
+
+// NewExponentialPolicy returns a Policy with sensible exponential-backoff
+// defaults: a 50ms initial delay doubling up to a 5s cap, with 20% jitter.
+func NewExponentialPolicy() Policy {
+	return Policy{
+		InitialDelay: DefaultBaseDelay,
+		MaxDelay:     5 * time.Second,
+		Multiplier:   DefaultMultiplier,
+		Jitter:       0.2,
+	}
+}
+
+func (p Policy) delay(attempt int) time.Duration {
+	d := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		factor := 1 - p.Jitter + rand.Float64()*2*p.Jitter
+		d *= factor
+	}
+	return time.Duration(d)
+}
+
+// ClassifyResult is returned by a classify function passed to DoWithPolicy,
+// reporting whether an error should be retried and, optionally, the exact
+// delay to retry after (e.g. one parsed from a Retry-After header).
+type ClassifyResult struct {
+	retry      bool
+	retryAfter time.Duration
+}
+
+// Retry reports that the error is transient and should be retried using the
+// policy's computed backoff.
+func Retry() ClassifyResult { return ClassifyResult{retry: true} }
+
+// Fail reports that the error is permanent and DoWithPolicy should return it
+// immediately.
+func Fail() ClassifyResult { return ClassifyResult{retry: false} }
+
+// RetryAfter reports that the error is transient and should be retried after
+// exactly d, overriding the policy's computed backoff.
+func RetryAfter(d time.Duration) ClassifyResult {
+	return ClassifyResult{retry: true, retryAfter: d}
+}
+
+// DoWithPolicy executes fn, retrying per policy based on classify's
+// judgment of the returned error. It honors ctx.Done() while waiting
+// between attempts.
+func DoWithPolicy(ctx context.Context, policy Policy, fn func() error, classify func(error) ClassifyResult) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		result := classify(err)
+		if !result.retry {
+			return err
+		}
+
+		wait := result.retryAfter
+		if wait == 0 {
+			wait = policy.delay(attempt)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoWithPolicy_SucceedsAfterRetries(t *testing.T) {
+	callCount := 0
+	fn := func() error {
+		callCount++
+		if callCount < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}
+
+	policy := Policy{InitialDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond, Multiplier: 2}
+	err := DoWithPolicy(context.Background(), policy, fn, func(error) ClassifyResult { return Retry() })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if callCount != 3 {
+		t.Errorf("expected 3 calls, got %d", callCount)
+	}
+}
+
+func TestDoWithPolicy_FailStopsImmediately(t *testing.T) {
+	callCount := 0
+	wantErr := errors.New("permanent")
+
+	err := DoWithPolicy(context.Background(), NewExponentialPolicy(), func() error {
+		callCount++
+		return wantErr
+	}, func(error) ClassifyResult { return Fail() })
+
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+	if callCount != 1 {
+		t.Errorf("expected 1 call, got %d", callCount)
+	}
+}
+
+func TestDoWithPolicy_RetryAfterOverridesDelay(t *testing.T) {
+	callCount := 0
+	start := time.Now()
+
+	err := DoWithPolicy(context.Background(), Policy{InitialDelay: time.Second, MaxDelay: time.Minute, Multiplier: 2}, func() error {
+		callCount++
+		if callCount < 2 {
+			return errors.New("rate limited")
+		}
+		return nil
+	}, func(error) ClassifyResult { return RetryAfter(5 * time.Millisecond) })
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("expected RetryAfter to override the 1s policy delay, took %v", elapsed)
+	}
+}
+
+func TestDoWithPolicy_HonorsContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := DoWithPolicy(ctx, Policy{InitialDelay: time.Second, MaxDelay: time.Minute, Multiplier: 2}, func() error {
+		return errors.New("transient")
+	}, func(error) ClassifyResult { return Retry() })
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
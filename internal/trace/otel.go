@@ -0,0 +1,117 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/yinghanhung/prr-playground/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("prr-playground")
+
+// StartSpan starts a new span named name as a child of any span already
+// active in ctx, returning the span-carrying context and the span itself.
+// Callers must end the span (typically via defer span.End()).
+func StartSpan(ctx context.Context, name string) (context.Context, oteltrace.Span) {
+	return tracer.Start(ctx, name)
+}
+
+// InitTracer wires up an OTLP/HTTP exporter read from
+// OTEL_EXPORTER_OTLP_ENDPOINT and registers it as the global
+// TracerProvider. If the endpoint is unset (the default), it no-ops and
+// returns a shutdown func that does nothing, so environments without a
+// collector (tests, local dev) keep working unmodified.
+func InitTracer(ctx context.Context) (func(context.Context) error, error) {
+	endpoint := config.GetString("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpoint(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(config.GetString("OTEL_SERVICE_NAME", "prr-playground"))),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tracing resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
+}
+
+// Extract pulls a W3C traceparent/tracestate out of header (if present) and
+// returns the resulting context, for handlers that want to start a span
+// from an inbound request without going through Middleware.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject writes the active span's W3C traceparent/tracestate (if any) into
+// header, for outbound requests that want downstream services to join the
+// same trace.
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Middleware extracts a W3C traceparent/tracestate from the incoming request
+// and starts a span for it, falling back to generating a trace ID and
+// propagating it via the legacy X-Trace-Id header when no traceparent is
+// present. Either way, the resulting trace ID is attached to the request
+// context via NewContext so existing FromContext callers (and the logger's
+// trace enrichment) keep working unchanged.
+func Middleware(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := Extract(r.Context(), r.Header)
+
+		var traceID string
+		if r.Header.Get("traceparent") != "" {
+			var span oteltrace.Span
+			ctx, span = tracer.Start(ctx, name)
+			defer span.End()
+			traceID = spanTraceID(ctx)
+		}
+		// A traceparent that yields no valid span (no propagator installed,
+		// or a malformed header) falls back the same way.
+		if traceID == "" {
+			traceID = r.Header.Get(HeaderName)
+			if traceID == "" {
+				traceID = New()
+			}
+		}
+
+		ctx = NewContext(ctx, traceID)
+		w.Header().Set(HeaderName, traceID)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// spanTraceID returns the trace ID of the active OpenTelemetry span in ctx,
+// or an empty string if ctx carries no valid span context.
+func spanTraceID(ctx context.Context) string {
+	sc := oteltrace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
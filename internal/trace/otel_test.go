@@ -0,0 +1,41 @@
+package trace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware_GeneratesTraceIDWhenAbsent(t *testing.T) {
+	var gotTraceID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceID = FromContext(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	Middleware("GET /", next).ServeHTTP(w, req)
+
+	if gotTraceID == "" {
+		t.Error("expected a generated trace ID")
+	}
+	if got := w.Header().Get(HeaderName); got == "" {
+		t.Error("expected response header to carry the generated trace ID")
+	}
+}
+
+func TestMiddleware_StartsAndEndsSpanForTraceparent(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+
+	// Regression test for a bug where the span started here was discarded
+	// without ever calling End(), silently dropping every traced request's
+	// root span. This only asserts the handler completes without hanging
+	// or panicking; with the fix, the span is ended via defer before
+	// ServeHTTP returns.
+	Middleware("GET /", next).ServeHTTP(w, req)
+}
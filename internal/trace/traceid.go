@@ -19,8 +19,14 @@ func New() string {
 	return uuid.New().String()
 }
 
-// FromContext retrieves the trace ID from the context, or returns an empty string if not found.
+// FromContext retrieves the trace ID from the context. It prefers the ID of
+// an active OpenTelemetry span (attached by Middleware/StartSpan), falling
+// back to the string stored by NewContext (e.g. one generated from a legacy
+// X-Trace-Id header), or an empty string if neither is present.
 func FromContext(ctx context.Context) string {
+	if traceID := spanTraceID(ctx); traceID != "" {
+		return traceID
+	}
 	if traceID, ok := ctx.Value(traceIDKey).(string); ok {
 		return traceID
 	}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// NewFanOut creates a Logger that writes every record to both a
+// human-readable text handler (stdout, for docker logs) and a JSON handler
+// (file, for a log shipper like Fluent Bit), replacing the old pattern of
+// maintaining two separate *log.Logger values and logging to each by hand.
+func NewFanOut(stdout, file io.Writer, prefix string) Logger {
+	opts := &slog.HandlerOptions{Level: LevelFromEnv()}
+	handler := &fanOutHandler{handlers: []slog.Handler{
+		slog.NewTextHandler(stdout, opts),
+		slog.NewJSONHandler(file, opts),
+	}}
+	return newLogger(handler, prefix)
+}
+
+type fanOutHandler struct {
+	handlers []slog.Handler
+}
+
+func (h *fanOutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fanOutHandler) Handle(ctx context.Context, record slog.Record) error {
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, record.Clone()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *fanOutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		next[i] = handler.WithAttrs(attrs)
+	}
+	return &fanOutHandler{handlers: next}
+}
+
+func (h *fanOutHandler) WithGroup(name string) slog.Handler {
+	next := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		next[i] = handler.WithGroup(name)
+	}
+	return &fanOutHandler{handlers: next}
+}
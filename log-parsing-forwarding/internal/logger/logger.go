@@ -0,0 +1,99 @@
+// Package logger provides structured JSON/text logging built on log/slog,
+// with context-aware request/trace ID injection and a Logger interface so
+// callers don't depend on a concrete handler implementation.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/yinghanhung/prr-playground/internal/config"
+	"github.com/yinghanhung/prr-playground/internal/trace"
+)
+
+type ctxKey string
+
+const requestIDKey ctxKey = "requestId"
+
+// Logger is the structured logging interface used throughout the service.
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	Debug(msg string, kv ...any)
+	Info(msg string, kv ...any)
+	Warn(msg string, kv ...any)
+	Error(msg string, kv ...any)
+	// WithContext returns a Logger that automatically attaches the request
+	// ID and trace ID carried by ctx, if any, to every subsequent record.
+	WithContext(ctx context.Context) Logger
+}
+
+type slogLogger struct {
+	base *slog.Logger
+	ctx  context.Context
+}
+
+// NewJSON creates a Logger backed by slog's JSON handler, suitable for file
+// output consumed by log shippers (e.g. Fluent Bit).
+func NewJSON(w io.Writer, prefix string) Logger {
+	return newLogger(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: LevelFromEnv()}), prefix)
+}
+
+// NewText creates a Logger backed by slog's text handler, suitable for
+// human-readable stdout output (e.g. docker logs).
+func NewText(w io.Writer, prefix string) Logger {
+	return newLogger(slog.NewTextHandler(w, &slog.HandlerOptions{Level: LevelFromEnv()}), prefix)
+}
+
+// New creates a Logger backed by slog's JSON handler. It exists for
+// backward compatibility with callers migrating from the old
+// `*log.Logger`-returning New; new call sites should prefer NewJSON/NewText.
+func New(w io.Writer, prefix string) Logger {
+	return NewJSON(w, prefix)
+}
+
+func newLogger(handler slog.Handler, prefix string) Logger {
+	base := slog.New(NewSamplingHandler(handler, DefaultSampleEvery))
+	if p := strings.TrimSpace(prefix); p != "" {
+		base = base.With("component", p)
+	}
+	return &slogLogger{base: base, ctx: context.Background()}
+}
+
+// LevelFromEnv reads LOG_LEVEL (debug|info|warn|error) via config.GetString,
+// defaulting to info when unset or unrecognized.
+func LevelFromEnv() slog.Level {
+	switch config.GetString("LOG_LEVEL", "info") {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// NewContext returns a context carrying requestID, retrievable by
+// WithContext.
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+func (l *slogLogger) WithContext(ctx context.Context) Logger {
+	base := l.base
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok && requestID != "" {
+		base = base.With("requestId", requestID)
+	}
+	if traceID := trace.FromContext(ctx); traceID != "" {
+		base = base.With("traceId", traceID)
+	}
+	return &slogLogger{base: base, ctx: ctx}
+}
+
+func (l *slogLogger) Debug(msg string, kv ...any) { l.base.DebugContext(l.ctx, msg, kv...) }
+func (l *slogLogger) Info(msg string, kv ...any)  { l.base.InfoContext(l.ctx, msg, kv...) }
+func (l *slogLogger) Warn(msg string, kv ...any)  { l.base.WarnContext(l.ctx, msg, kv...) }
+func (l *slogLogger) Error(msg string, kv ...any) { l.base.ErrorContext(l.ctx, msg, kv...) }
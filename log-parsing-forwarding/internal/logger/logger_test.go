@@ -2,15 +2,21 @@ package logger
 
 import (
 	"bytes"
+	"context"
+	"io"
+	"log/slog"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/yinghanhung/prr-playground/internal/trace"
 )
 
 func TestNew(t *testing.T) {
 	var buf bytes.Buffer
-	logger := New(&buf, "[TEST] ")
+	logger := New(&buf, "[TEST]")
 
-	logger.Println("test message")
+	logger.Info("test message")
 
 	output := buf.String()
 	if !strings.Contains(output, "[TEST]") {
@@ -24,11 +30,11 @@ func TestNew(t *testing.T) {
 func TestNewMultipleLoggers(t *testing.T) {
 	var buf1, buf2 bytes.Buffer
 
-	logger1 := New(&buf1, "[LOG1] ")
-	logger2 := New(&buf2, "[LOG2] ")
+	logger1 := New(&buf1, "[LOG1]")
+	logger2 := New(&buf2, "[LOG2]")
 
-	logger1.Println("message 1")
-	logger2.Println("message 2")
+	logger1.Info("message 1")
+	logger2.Info("message 2")
 
 	out1 := buf1.String()
 	out2 := buf2.String()
@@ -40,3 +46,63 @@ func TestNewMultipleLoggers(t *testing.T) {
 		t.Errorf("Logger2 output incorrect: %v", out2)
 	}
 }
+
+func TestWithContext_InjectsTraceID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "")
+
+	ctx := trace.NewContext(context.Background(), "trace-123")
+	logger.WithContext(ctx).Info("handled request")
+
+	output := buf.String()
+	if !strings.Contains(output, "trace-123") {
+		t.Errorf("expected traceId in output, got: %v", output)
+	}
+}
+
+func TestWithContext_InjectsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "")
+
+	ctx := NewContext(context.Background(), "req-456")
+	logger.WithContext(ctx).Info("handled request")
+
+	output := buf.String()
+	if !strings.Contains(output, "req-456") {
+		t.Errorf("expected requestId in output, got: %v", output)
+	}
+}
+
+func TestSamplingHandler_DropsBetweenSamples(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewSamplingHandler(slog.NewJSONHandler(&buf, nil), 3)
+	l := slog.New(handler)
+
+	for i := 0; i < 6; i++ {
+		l.Info("high volume event")
+	}
+
+	if got := strings.Count(buf.String(), "high volume event"); got != 2 {
+		t.Errorf("expected 2 sampled lines out of 6, got %d", got)
+	}
+}
+
+// TestSamplingHandler_WithAttrsSharesMutex guards against WithAttrs/
+// WithGroup handing out a fresh zero-value mutex over the still-shared
+// counts map - every derived handler must serialize on the same lock the
+// original handler uses, or concurrent callers race on the map. Run with
+// -race to catch a regression.
+func TestSamplingHandler_WithAttrsSharesMutex(t *testing.T) {
+	handler := NewSamplingHandler(slog.NewJSONHandler(io.Discard, nil), 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			derived := handler.WithAttrs([]slog.Attr{slog.Int("worker", i)})
+			_ = derived.Handle(context.Background(), slog.Record{Message: "concurrent"})
+		}(i)
+	}
+	wg.Wait()
+}
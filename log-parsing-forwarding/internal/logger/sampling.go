@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// DefaultSampleEvery is the sampling rate used by New/NewJSON/NewText: one of
+// every N occurrences of an identical message is emitted, the rest are dropped.
+const DefaultSampleEvery = 1
+
+// NewSamplingHandler wraps next, emitting only the 1st, (sampleEvery+1)th,
+// (2*sampleEvery+1)th, ... record for a given message so a high-volume,
+// repetitive event doesn't flood the log sink. sampleEvery <= 1 disables
+// sampling (every record is emitted).
+func NewSamplingHandler(next slog.Handler, sampleEvery int) slog.Handler {
+	if sampleEvery <= 1 {
+		return next
+	}
+	return &samplingHandler{next: next, sampleEvery: sampleEvery, mu: &sync.Mutex{}, counts: make(map[string]int)}
+}
+
+type samplingHandler struct {
+	next        slog.Handler
+	sampleEvery int
+
+	// mu and counts are shared by pointer with every handler WithAttrs/
+	// WithGroup derive from this one, so concurrent slog.With calls still
+	// guard the same counts map instead of each getting its own zero-value
+	// mutex over a map they all alias.
+	mu     *sync.Mutex
+	counts map[string]int
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.mu.Lock()
+	h.counts[record.Message]++
+	count := h.counts[record.Message]
+	h.mu.Unlock()
+
+	if count%h.sampleEvery != 1 {
+		return nil
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), sampleEvery: h.sampleEvery, mu: h.mu, counts: h.counts}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), sampleEvery: h.sampleEvery, mu: h.mu, counts: h.counts}
+}
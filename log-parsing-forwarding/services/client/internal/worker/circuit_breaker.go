@@ -0,0 +1,125 @@
+package worker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yinghanhung/prr-playground/internal/logger"
+)
+
+// ErrCircuitOpen is returned by doRequestWithRetry (and surfaces as
+// JobResult.Err from Submit/RunWithResults) when the circuit breaker is
+// open and the request was skipped without hitting the network.
+var ErrCircuitOpen = errors.New("worker: circuit breaker open")
+
+// circuitState is one of the three states in the classic Hystrix-style
+// breaker: closed (requests flow normally), open (requests are
+// short-circuited), half-open (a single probe request is allowed through
+// to test recovery).
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker trips a Pool's target from closed to open after enough
+// consecutive failures (network errors or 5xx), so a broken downstream
+// doesn't consume the full Total*(MaxRetries+1) request budget. After
+// cooldown elapses it moves to half-open and allows exactly one probe
+// through, closing again on success or re-opening on failure.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	log       logger.Logger
+
+	mu       sync.Mutex
+	state    circuitState
+	fails    int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, log logger.Logger) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, log: log}
+}
+
+// allow reports whether a request may proceed. While open it returns false
+// until cooldown has elapsed, at which point it admits exactly one caller
+// as a half-open probe and returns false to everyone else until that probe
+// resolves via recordSuccess/recordFailure.
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case circuitClosed:
+		return true
+	case circuitOpen:
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return false
+		}
+		cb.transition(circuitHalfOpen)
+		return true
+	default: // circuitHalfOpen: a probe is already in flight
+		return false
+	}
+}
+
+// recordSuccess reports a request outcome that should count toward
+// closing the breaker.
+func (cb *circuitBreaker) recordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.fails = 0
+	cb.transition(circuitClosed)
+}
+
+// recordFailure reports a request outcome that should count toward
+// opening (or re-opening) the breaker.
+func (cb *circuitBreaker) recordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == circuitHalfOpen {
+		cb.openedAt = time.Now()
+		cb.transition(circuitOpen)
+		return
+	}
+
+	cb.fails++
+	if cb.fails >= cb.threshold {
+		cb.openedAt = time.Now()
+		cb.transition(circuitOpen)
+	}
+}
+
+// transition must be called with mu held.
+func (cb *circuitBreaker) transition(to circuitState) {
+	from := cb.state
+	cb.state = to
+	if from != to {
+		cb.log.Info("circuit breaker state change", "from", from.String(), "to", to.String())
+	}
+}
+
+// State returns the breaker's current state as a string ("closed", "open",
+// or "half-open"), for tests and diagnostics.
+func (cb *circuitBreaker) State() string {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state.String()
+}
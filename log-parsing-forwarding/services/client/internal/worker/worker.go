@@ -3,15 +3,34 @@ package worker
 
 import (
 	"context"
-	"log"
+	"math/rand"
 	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/yinghanhung/prr-playground/internal/retry"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/yinghanhung/prr-playground/internal/logger"
 	"github.com/yinghanhung/prr-playground/internal/trace"
 )
 
+const (
+	// defaultRetryBaseDelay is the backoff used for Config.RetryBaseDelay
+	// when it's left unset.
+	defaultRetryBaseDelay = 100 * time.Millisecond
+	// defaultRetryMaxDelay is the backoff cap used for Config.RetryMaxDelay
+	// when it's left unset.
+	defaultRetryMaxDelay = 10 * time.Second
+	// defaultCircuitFailureThreshold is the consecutive-failure count used
+	// for Config.CircuitFailureThreshold when it's left unset.
+	defaultCircuitFailureThreshold = 5
+	// defaultCircuitCooldown is the cooldown used for Config.CircuitCooldown
+	// when it's left unset.
+	defaultCircuitCooldown = 10 * time.Second
+)
+
 // Config holds the worker pool configuration.
 type Config struct {
 	TargetURL   string
@@ -20,26 +39,94 @@ type Config struct {
 	Interval    time.Duration
 	Timeout     time.Duration
 	MaxRetries  int
+
+	// RetryBaseDelay is the upper bound of the randomized (full jitter)
+	// backoff before the first retry; the bound doubles on each subsequent
+	// attempt, capped at RetryMaxDelay. Defaults to 100ms.
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps the exponential backoff between retries. Defaults
+	// to 10s.
+	RetryMaxDelay time.Duration
+
+	// CircuitFailureThreshold is the number of consecutive failures
+	// (network errors or 5xx) that trips the circuit breaker from closed
+	// to open. Defaults to 5.
+	CircuitFailureThreshold int
+	// CircuitCooldown is how long the breaker stays open before allowing a
+	// single half-open probe request. Defaults to 10s.
+	CircuitCooldown time.Duration
 }
 
 // Pool manages a pool of HTTP client workers.
 type Pool struct {
-	config Config
-	client *http.Client
+	config  Config
+	client  *http.Client
+	log     logger.Logger
+	breaker *circuitBreaker
+
+	circuitOpenCount atomic.Int64
 }
 
-// NewPool creates a new worker pool with the given configuration.
-func NewPool(cfg Config) *Pool {
+// NewPool creates a new worker pool with the given configuration, logging
+// through log. Per-attempt retry chatter is only emitted at Debug, so a
+// noisy target doesn't flood Info-level output.
+func NewPool(cfg Config, log logger.Logger) *Pool {
+	threshold := cfg.CircuitFailureThreshold
+	if threshold <= 0 {
+		threshold = defaultCircuitFailureThreshold
+	}
+	cooldown := cfg.CircuitCooldown
+	if cooldown <= 0 {
+		cooldown = defaultCircuitCooldown
+	}
+
 	return &Pool{
-		config: cfg,
-		client: &http.Client{Timeout: cfg.Timeout},
+		config:  cfg,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		log:     log,
+		breaker: newCircuitBreaker(threshold, cooldown, log),
 	}
 }
 
+// CircuitState returns the pool's circuit breaker state ("closed", "open",
+// or "half-open"), for tests and diagnostics.
+func (p *Pool) CircuitState() string {
+	return p.breaker.State()
+}
+
+// CircuitOpenCount returns the number of requests skipped so far because
+// the circuit breaker was open (worker_circuit_open_total).
+func (p *Pool) CircuitOpenCount() int64 {
+	return p.circuitOpenCount.Load()
+}
+
+// JobRequest describes a single job to submit to the pool. Every job hits
+// the same configured TargetURL today, so JobRequest only carries the
+// bookkeeping ID used to correlate it with the returned JobResult; per-job
+// overrides (a distinct URL or body) can be added here later without
+// changing the Submit/RunWithResults signatures.
+type JobRequest struct {
+	JobID int
+}
+
+// JobResult is the outcome of a single job run through the pool, returned by
+// Submit and RunWithResults so a caller (a test, or an HTTP handler wanting
+// an aggregated result) can inspect what happened instead of only reading
+// log output.
+type JobResult struct {
+	JobID      int
+	TraceID    string
+	StatusCode int
+	Latency    time.Duration
+	Attempts   int
+	Err        error
+}
+
 // Run executes the load test with the configured number of workers and requests.
 func (p *Pool) Run() {
-	log.Printf("starting client target=%s total=%d concurrency=%d interval=%s",
-		p.config.TargetURL, p.config.Total, p.config.Concurrency, p.config.Interval)
+	p.log.Info("starting client",
+		"target", p.config.TargetURL, "total", p.config.Total,
+		"concurrency", p.config.Concurrency, "interval", p.config.Interval.String())
 
 	jobs := make(chan int, p.config.Total)
 
@@ -55,93 +142,247 @@ func (p *Pool) Run() {
 	close(jobs)
 
 	wg.Wait()
-	log.Println("client finished")
+	p.log.Info("client finished")
 }
 
 func (p *Pool) worker(id int, jobs <-chan int, wg *sync.WaitGroup) {
 	defer wg.Done()
 	for job := range jobs {
-		traceID := trace.New()
-		success, latency := p.doRequestWithRetry(id, job, traceID)
+		result := p.runJob(context.Background(), id, job)
 
-		if success {
-			log.Printf("[worker %d] request %d ok (trace %s) latency=%s", id, job, traceID, latency)
+		if result.Err == nil && result.StatusCode < 400 {
+			p.log.Info("request ok",
+				"workerId", id, "jobId", job, "traceId", result.TraceID,
+				"status", "ok", "latencyMs", result.Latency.Milliseconds())
 		}
 
 		time.Sleep(p.config.Interval)
 	}
 }
 
-func (p *Pool) doRequestWithRetry(workerID, jobID int, traceID string) (bool, time.Duration) {
-	var lastErr error
-	var lastStatusCode int
-	var lastLatency time.Duration
+// RunWithResults runs the same workload as Run but, instead of only logging
+// outcomes, collects every job's JobResult and returns them once all workers
+// finish (or ctx is cancelled). Useful from tests, or from a caller that
+// wants the aggregated results rather than having Run discard them.
+func (p *Pool) RunWithResults(ctx context.Context) ([]JobResult, error) {
+	p.log.Info("starting client",
+		"target", p.config.TargetURL, "total", p.config.Total,
+		"concurrency", p.config.Concurrency, "interval", p.config.Interval.String())
+
+	jobs := make(chan int, p.config.Total)
+	results := make([]JobResult, p.config.Total)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.config.Concurrency; i++ {
+		wg.Add(1)
+		go p.resultWorker(ctx, i, jobs, results, &wg)
+	}
+
+	for i := 0; i < p.config.Total; i++ {
+		jobs <- i + 1
+	}
+	close(jobs)
+
+	wg.Wait()
+	p.log.Info("client finished")
+
+	return results, ctx.Err()
+}
+
+func (p *Pool) resultWorker(ctx context.Context, id int, jobs <-chan int, results []JobResult, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for job := range jobs {
+		// job is 1-indexed (see Run/RunWithResults), results is 0-indexed.
+		results[job-1] = p.runJob(ctx, id, job)
 
-	isRetryable := func(err error) bool {
-		if err != nil {
-			return true // Network errors are retryable
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.config.Interval):
 		}
-		// 5xx errors are retryable, 4xx (except 429) are not
-		return lastStatusCode >= 500 || lastStatusCode == 429
+	}
+}
+
+// Submit runs a single job synchronously through the same retry logic as the
+// pool's workers and returns its JobResult. Useful from tests, or from an
+// HTTP handler that wants to enqueue one job and wait for its outcome
+// instead of firing into the discard-everything Run loop.
+func (p *Pool) Submit(ctx context.Context, req JobRequest) (JobResult, error) {
+	result := p.runJob(ctx, 0, req.JobID)
+	return result, ctx.Err()
+}
+
+func (p *Pool) runJob(ctx context.Context, workerID, jobID int) JobResult {
+	traceID := trace.New()
+	statusCode, attempts, latency, err := p.doRequestWithRetry(ctx, workerID, jobID, traceID)
+	return JobResult{
+		JobID:      jobID,
+		TraceID:    traceID,
+		StatusCode: statusCode,
+		Latency:    latency,
+		Attempts:   attempts,
+		Err:        err,
+	}
+}
+
+// backoffWithFullJitter picks a randomized delay for the given zero-based
+// retry number, following the "full jitter" strategy: uniformly random
+// between 0 and min(cap, base*2^attempt). Spreading retries out like this
+// keeps a pool of workers from all hammering the target in lockstep the way
+// a fixed interval would.
+func backoffWithFullJitter(base, cap time.Duration, retry int) time.Duration {
+	upper := base << retry // base * 2^retry
+	if retry > 62 || upper <= 0 || upper > cap {
+		upper = cap
+	}
+	if upper <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(upper)))
+}
+
+// parseRetryAfter parses a Retry-After header in either form RFC 7231
+// allows: delta-seconds ("120") or an HTTP-date. ok is false if header is
+// empty or unparseable.
+func parseRetryAfter(header string, now time.Time) (d time.Duration, ok bool) {
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (p *Pool) retryBaseDelay() time.Duration {
+	if p.config.RetryBaseDelay > 0 {
+		return p.config.RetryBaseDelay
+	}
+	return defaultRetryBaseDelay
+}
+
+func (p *Pool) retryMaxDelay() time.Duration {
+	if p.config.RetryMaxDelay > 0 {
+		return p.config.RetryMaxDelay
+	}
+	return defaultRetryMaxDelay
+}
+
+func (p *Pool) doRequestWithRetry(ctx context.Context, workerID, jobID int, traceID string) (statusCode int, attempts int, latency time.Duration, err error) {
+	if !p.breaker.allow() {
+		p.circuitOpenCount.Add(1)
+		p.log.Warn("circuit breaker open, skipping request",
+			"workerId", workerID, "jobId", jobID, "traceId", traceID)
+		return 0, 0, 0, ErrCircuitOpen
 	}
 
-	attempt := 0
-	err := retry.Do(context.Background(), p.config.MaxRetries, func() error {
-		req, err := http.NewRequest(http.MethodGet, p.config.TargetURL, nil)
-		if err != nil {
-			log.Printf("[worker %d] request %d build error (trace %s): %v", workerID, jobID, traceID, err)
-			return err
+	defer func() {
+		if err != nil || statusCode >= 500 {
+			p.breaker.recordFailure()
+		} else {
+			p.breaker.recordSuccess()
 		}
-		req.Header.Set(trace.HeaderName, traceID)
+	}()
 
-		start := time.Now()
-		resp, err := p.client.Do(req)
-		lastLatency = time.Since(start)
+	var lastErr error
+	var lastStatusCode int
+	var lastLatency time.Duration
+	maxAttempts := p.config.MaxRetries + 1
 
-		if err != nil {
-			lastErr = err
+	for attempts = 1; attempts <= maxAttempts; attempts++ {
+		var retryAfter time.Duration
+		var retryAfterOK bool
+
+		attemptCtx, span := trace.StartSpan(ctx, "worker.doRequest")
+		span.SetAttributes(attribute.Int("retry.attempt", attempts))
+
+		req, reqErr := http.NewRequestWithContext(attemptCtx, http.MethodGet, p.config.TargetURL, nil)
+		if reqErr != nil {
+			p.log.Error("request build error",
+				"workerId", workerID, "jobId", jobID, "traceId", traceID, "error", reqErr)
+			lastErr = reqErr
 			lastStatusCode = 0
-			if attempt > 0 {
-				log.Printf("[worker %d] request %d failed (trace %s) attempt %d/%d: %v",
-					workerID, jobID, traceID, attempt+1, p.config.MaxRetries+1, err)
+		} else {
+			// X-Trace-Id is kept alongside the W3C traceparent injected by
+			// trace.Inject so downstream services that haven't adopted OTel
+			// yet can still correlate via the legacy header.
+			req.Header.Set(trace.HeaderName, traceID)
+			trace.Inject(attemptCtx, req.Header)
+
+			start := time.Now()
+			resp, doErr := p.client.Do(req)
+			lastLatency = time.Since(start)
+
+			if doErr != nil {
+				lastErr = doErr
+				lastStatusCode = 0
+			} else {
+				lastErr = nil
+				lastStatusCode = resp.StatusCode
+				if lastStatusCode == http.StatusTooManyRequests || lastStatusCode == http.StatusServiceUnavailable {
+					retryAfter, retryAfterOK = parseRetryAfter(resp.Header.Get("Retry-After"), start)
+				}
+				_ = resp.Body.Close()
 			}
-			attempt++
-			return err
 		}
 
-		lastStatusCode = resp.StatusCode
-		_ = resp.Body.Close()
+		if lastStatusCode != 0 {
+			span.SetAttributes(attribute.Int("http.status_code", lastStatusCode))
+		}
+		span.End()
 
 		// Success case
-		if lastStatusCode < 400 {
-			if attempt > 0 {
-				log.Printf("[worker %d] request %d succeeded on retry %d (trace %s) status=%d latency=%s",
-					workerID, jobID, attempt, traceID, lastStatusCode, lastLatency)
+		if lastErr == nil && lastStatusCode < 400 {
+			if attempts > 1 {
+				p.log.Info("request succeeded on retry",
+					"workerId", workerID, "jobId", jobID, "traceId", traceID,
+					"attempt", attempts, "status", lastStatusCode, "latencyMs", lastLatency.Milliseconds())
 			}
-			return nil
+			return lastStatusCode, attempts, lastLatency, nil
 		}
 
-		// Failed with status code >= 400
-		if !isRetryable(nil) {
-			log.Printf("[worker %d] request %d failed non-retryable (trace %s) status=%d",
-				workerID, jobID, traceID, lastStatusCode)
-			return nil // Don't retry
+		// 5xx/429/network errors are retryable, 4xx (except 429) are not
+		retryable := lastErr != nil || lastStatusCode >= 500 || lastStatusCode == 429
+		if !retryable {
+			p.log.Warn("request failed non-retryable",
+				"workerId", workerID, "jobId", jobID, "traceId", traceID, "status", lastStatusCode)
+			return lastStatusCode, attempts, lastLatency, nil
 		}
 
-		if attempt > 0 {
-			log.Printf("[worker %d] request %d failed (trace %s) attempt %d/%d status=%d",
-				workerID, jobID, traceID, attempt+1, p.config.MaxRetries+1, lastStatusCode)
+		if attempts == maxAttempts {
+			break
 		}
-		attempt++
-		lastErr = http.ErrServerClosed // Dummy error to indicate failure
-		return lastErr
-	}, isRetryable)
 
-	if err != nil {
-		log.Printf("[worker %d] request %d failed after %d retries (trace %s) status=%d: %v",
-			workerID, jobID, p.config.MaxRetries, traceID, lastStatusCode, lastErr)
-		return false, lastLatency
+		sleep := backoffWithFullJitter(p.retryBaseDelay(), p.retryMaxDelay(), attempts-1)
+		if retryAfterOK && retryAfter > sleep {
+			sleep = retryAfter
+		}
+
+		p.log.Debug("request failed, retrying",
+			"workerId", workerID, "jobId", jobID, "traceId", traceID,
+			"attempt", attempts, "maxAttempts", maxAttempts, "sleepMs", sleep.Milliseconds(),
+			"status", lastStatusCode, "error", lastErr)
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return lastStatusCode, attempts, lastLatency, ctx.Err()
+		case <-timer.C:
+		}
 	}
 
-	return lastStatusCode < 400, lastLatency
+	p.log.Warn("request failed after retries",
+		"workerId", workerID, "jobId", jobID, "traceId", traceID,
+		"attempts", attempts, "status", lastStatusCode, "error", lastErr)
+	return lastStatusCode, attempts, lastLatency, lastErr
 }
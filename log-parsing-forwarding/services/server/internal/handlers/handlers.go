@@ -4,36 +4,16 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
+	"github.com/yinghanhung/prr-playground/internal/logger"
 	"github.com/yinghanhung/prr-playground/internal/trace"
 	"github.com/yinghanhung/prr-playground/services/server/internal/metrics"
 )
 
-type logEntry struct {
-	TraceID   string `json:"traceId"`
-	Method    string `json:"method"`
-	Path      string `json:"path"`
-	Status    int    `json:"status"`
-	LatencyMs int64  `json:"latencyMs,omitempty"`
-	Message   string `json:"message"`
-}
-
-func logJSON(stdoutLogger *log.Logger, fileLogger *log.Logger, entry logEntry) {
-	b, err := json.Marshal(entry)
-	if err != nil {
-		stdoutLogger.Printf(`{"message":"failed to marshal log","error":"%v"}\n`, err)
-		fileLogger.Printf(`{"message":"failed to marshal log","error":"%v"}\n`, err)
-		return
-	}
-	stdoutLogger.Println(string(b))
-	fileLogger.Printf("%s\n", string(b))
-}
-
 // Hello returns a handler for the main hello endpoint.
-func Hello(stdoutLogger *log.Logger, fileLogger *log.Logger) http.HandlerFunc {
+func Hello(log logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		traceID := trace.FromContext(r.Context())
 		resp := map[string]string{
@@ -43,29 +23,28 @@ func Hello(stdoutLogger *log.Logger, fileLogger *log.Logger) http.HandlerFunc {
 		}
 		time.Sleep(50 * time.Millisecond) // simulate work
 
+		reqLog := log.WithContext(r.Context())
+
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
-			logJSON(stdoutLogger, fileLogger, logEntry{
-				TraceID: traceID,
-				Method:  r.Method,
-				Path:    r.URL.Path,
-				Status:  http.StatusInternalServerError,
-				Message: "failed to encode response",
-			})
+			reqLog.Error("failed to encode response",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", http.StatusInternalServerError,
+				"error", err,
+			)
 			return
 		}
 
-		logJSON(stdoutLogger, fileLogger, logEntry{
-			TraceID: traceID,
-			Method:  r.Method,
-			Path:    r.URL.Path,
-			Status:  http.StatusOK,
-			Message: "handler finished",
-		})
+		reqLog.Info("handler finished",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", http.StatusOK,
+		)
 	}
 }
 
-// Health returns a handler for the health check endpoint.
+// Health returns a handler for the liveness check endpoint.
 func Health() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -77,6 +56,21 @@ func Health() http.HandlerFunc {
 	}
 }
 
+// Ready returns a handler for the readiness check endpoint. The server has
+// no external dependencies to probe today, so it reports ready as soon as
+// it's serving; the separate endpoint exists so a future dependency check
+// (e.g. a downstream the hello handler calls) has a natural home.
+func Ready() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "ready",
+			"service": "prr-playground-server",
+		})
+	}
+}
+
 // Metrics returns a handler for the metrics endpoint.
 func Metrics(collector *metrics.Collector) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -89,6 +83,9 @@ func Metrics(collector *metrics.Collector) http.HandlerFunc {
 		fmt.Fprintf(w, "# HELP http_errors_total Total number of HTTP errors (4xx, 5xx)\n")
 		fmt.Fprintf(w, "# TYPE http_errors_total counter\n")
 		fmt.Fprintf(w, "http_errors_total %d\n", stats.ErrorCount)
+		fmt.Fprintf(w, "# HELP http_server_panics_total Total number of HTTP handler panics recovered\n")
+		fmt.Fprintf(w, "# TYPE http_server_panics_total counter\n")
+		fmt.Fprintf(w, "http_server_panics_total %d\n", stats.PanicCount)
 		fmt.Fprintf(w, "# HELP http_request_duration_ms Average request latency in milliseconds\n")
 		fmt.Fprintf(w, "# TYPE http_request_duration_ms gauge\n")
 		fmt.Fprintf(w, "http_request_duration_ms %d\n", stats.AvgLatencyMs)
@@ -0,0 +1,70 @@
+// Package metrics provides metrics collection for HTTP requests.
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Collector collects HTTP request metrics.
+type Collector struct {
+	requestCount   atomic.Int64
+	errorCount     atomic.Int64
+	panicCount     atomic.Int64
+	totalLatencyMs atomic.Int64
+}
+
+// Stats represents collected metrics statistics.
+type Stats struct {
+	RequestCount int64
+	ErrorCount   int64
+	PanicCount   int64
+	AvgLatencyMs int64
+}
+
+// NewCollector creates a new metrics collector.
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// RecordRequest increments the request counter.
+func (c *Collector) RecordRequest() {
+	c.requestCount.Add(1)
+}
+
+// RecordError increments the error counter.
+func (c *Collector) RecordError() {
+	c.errorCount.Add(1)
+}
+
+// RecordPanic increments the panic counter, recorded separately from
+// RecordError since a recovered panic always implies a 5xx response but not
+// every 5xx implies a panic.
+func (c *Collector) RecordPanic() {
+	c.panicCount.Add(1)
+}
+
+// RecordLatency adds latency to the total.
+func (c *Collector) RecordLatency(d time.Duration) {
+	c.totalLatencyMs.Add(d.Milliseconds())
+}
+
+// GetStats returns the current metrics statistics.
+func (c *Collector) GetStats() Stats {
+	reqCount := c.requestCount.Load()
+	errCount := c.errorCount.Load()
+	panicCount := c.panicCount.Load()
+	totalLatency := c.totalLatencyMs.Load()
+
+	var avgLatency int64
+	if reqCount > 0 {
+		avgLatency = totalLatency / reqCount
+	}
+
+	return Stats{
+		RequestCount: reqCount,
+		ErrorCount:   errCount,
+		PanicCount:   panicCount,
+		AvgLatencyMs: avgLatency,
+	}
+}
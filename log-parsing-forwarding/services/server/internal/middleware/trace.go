@@ -0,0 +1,103 @@
+// Package middleware provides HTTP middleware for the server.
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/yinghanhung/prr-playground/internal/logger"
+	"github.com/yinghanhung/prr-playground/internal/trace"
+	"github.com/yinghanhung/prr-playground/services/server/internal/metrics"
+)
+
+// StatusRecorder wraps http.ResponseWriter to capture the status code.
+type StatusRecorder struct {
+	http.ResponseWriter
+	Status int
+}
+
+// WriteHeader captures the status code before writing it.
+func (r *StatusRecorder) WriteHeader(status int) {
+	r.Status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Trace returns middleware that adds a trace ID to the request context,
+// records request metrics, and logs the completed request via log.
+//
+// It extracts a W3C traceparent if the caller sent one and starts a span for
+// it, falling back to the legacy X-Trace-Id header (or a newly generated ID)
+// so trace.FromContext keeps working either way.
+func Trace(log logger.Logger, collector *metrics.Collector, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		ctx := trace.Extract(r.Context(), r.Header)
+		var traceID string
+		if r.Header.Get("traceparent") != "" {
+			var span oteltrace.Span
+			ctx, span = trace.StartSpan(ctx, r.Method+" "+r.URL.Path)
+			defer span.End()
+			traceID = trace.FromContext(ctx)
+		} else {
+			traceID = r.Header.Get(trace.HeaderName)
+			if traceID == "" {
+				traceID = trace.New()
+			}
+		}
+
+		ctx = trace.NewContext(ctx, traceID)
+		rec := &StatusRecorder{ResponseWriter: w, Status: http.StatusOK}
+		w.Header().Set(trace.HeaderName, traceID)
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		latency := time.Since(start)
+		collector.RecordRequest()
+		if rec.Status >= 400 {
+			collector.RecordError()
+		}
+		collector.RecordLatency(latency)
+
+		log.WithContext(ctx).Info("request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.Status,
+			"latencyMs", latency.Milliseconds(),
+		)
+	})
+}
+
+// Recover wraps next with panic recovery: a recovered panic is logged
+// (panic value, stack trace, plus whatever trace/request ID log.WithContext
+// already attaches from ctx), counted via collector.RecordPanic, and
+// answered with a 500 instead of crashing the server. Install it outermost
+// (before Trace) so a panic from inside Trace or next is still caught and
+// still has a trace ID attached by the time Recover runs.
+func Recover(log logger.Logger, collector *metrics.Collector, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				collector.RecordPanic()
+				// Trace attaches its trace ID to a derived request this
+				// handler never sees, but also sets it on the shared response
+				// header, so take it from there.
+				ctx := r.Context()
+				if traceID := w.Header().Get(trace.HeaderName); traceID != "" {
+					ctx = trace.NewContext(ctx, traceID)
+				}
+				log.WithContext(ctx).Error("panic recovered",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"panic", recovered,
+					"stack", string(debug.Stack()),
+				)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -2,16 +2,19 @@ package main
 
 import (
 	"context"
-	"log"
+	"expvar"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/yinghanhung/prr-playground/internal/config"
 	"github.com/yinghanhung/prr-playground/internal/logger"
+	"github.com/yinghanhung/prr-playground/internal/trace"
 	"github.com/yinghanhung/prr-playground/services/server/internal/handlers"
 	"github.com/yinghanhung/prr-playground/services/server/internal/metrics"
 	"github.com/yinghanhung/prr-playground/services/server/internal/middleware"
@@ -20,6 +23,8 @@ import (
 const (
 	defaultLogPath         = "/var/log/app/app.log"
 	defaultPort            = "8080"
+	defaultAdminPort       = "9090"
+	defaultAdminBind       = "127.0.0.1"
 	defaultShutdownTimeout = 10 * time.Second
 )
 
@@ -30,43 +35,98 @@ func ensureLogFile(path string) (*os.File, error) {
 	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
 }
 
+// newAdminMux builds the scrape/ops-only routes: metrics, health, readiness
+// and pprof profiling, kept off the public listener so scrape traffic can't
+// compete with user requests for the public server's timeout budgets (or
+// reach it at all, once ADMIN_BIND is restricted to loopback).
+func newAdminMux(collector *metrics.Collector) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", handlers.Health())
+	mux.HandleFunc("/healthz/ready", handlers.Ready())
+	mux.HandleFunc("/metrics", handlers.Metrics(collector))
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// shutdownAll shuts down every server in parallel, each bounded by ctx, so a
+// slow admin listener can't eat into the public listener's share of the
+// shutdown timeout (or vice versa).
+func shutdownAll(ctx context.Context, log logger.Logger, servers map[string]*http.Server) {
+	var wg sync.WaitGroup
+	for name, server := range servers {
+		wg.Add(1)
+		go func(name string, server *http.Server) {
+			defer wg.Done()
+			if err := server.Shutdown(ctx); err != nil {
+				log.Error("server shutdown error", "server", name, "error", err)
+				server.Close()
+			} else {
+				log.Info("server shutdown gracefully", "server", name)
+			}
+		}(name, server)
+	}
+	wg.Wait()
+}
+
 func main() {
 	// Load configuration
 	logPath := config.GetString("LOG_PATH", defaultLogPath)
 	port := config.GetString("PORT", defaultPort)
+	adminPort := config.GetString("ADMIN_PORT", defaultAdminPort)
+	adminBind := config.GetString("ADMIN_BIND", defaultAdminBind)
+	adminUser := config.GetString("ADMIN_USER", "")
+	adminPassword := config.GetString("ADMIN_PASSWORD", "")
 	shutdownTimeout := config.GetDuration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout)
 
-	// Setup logging
+	// Bootstrap logger for failures before the log file is available.
+	bootLog := logger.NewText(os.Stdout, "")
+
 	logFile, err := ensureLogFile(logPath)
 	if err != nil {
-		log.Fatalf("cannot init log file: %v", err)
+		bootLog.Error("cannot init log file", "error", err)
+		os.Exit(1)
 	}
 	defer func() {
 		if err := logFile.Sync(); err != nil {
-			log.Printf("failed to sync log file: %v", err)
+			bootLog.Error("failed to sync log file", "error", err)
 		}
 		if err := logFile.Close(); err != nil {
-			log.Printf("failed to close log file: %v", err)
+			bootLog.Error("failed to close log file", "error", err)
 		}
 	}()
 
-	// Create loggers: stdout with timestamp, file without timestamp for JSON parsing
-	stdoutLogger := logger.New(os.Stdout, "")
-	fileLogger := logger.New(logFile, "")
+	// Text to stdout for docker logs, JSON to file for Fluent Bit parsing.
+	log := logger.NewFanOut(os.Stdout, logFile, "")
+
+	shutdownTracer, err := trace.InitTracer(context.Background())
+	if err != nil {
+		log.Error("failed to initialize tracer", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracer(context.Background()); err != nil {
+			log.Error("failed to shut down tracer", "error", err)
+		}
+	}()
 
 	// Setup metrics collector
 	collector := metrics.NewCollector()
 
-	// Setup HTTP routes
+	// Setup public HTTP routes: business traffic only
 	mux := http.NewServeMux()
-	mux.Handle("/hello", handlers.Hello(stdoutLogger, fileLogger))
-	mux.HandleFunc("/health", handlers.Health())
-	mux.HandleFunc("/metrics", handlers.Metrics(collector))
+	mux.Handle("/hello", handlers.Hello(log))
 
-	// Wrap with middleware
-	handler := middleware.Trace(stdoutLogger, fileLogger, collector, mux)
+	// Wrap with middleware. Recover is outermost so a panic anywhere inside
+	// Trace or mux is still caught, logged with its trace ID, and answered
+	// with a 500 instead of crashing the process.
+	handler := middleware.Recover(log, collector, middleware.Trace(log, collector, mux))
 
-	// Create HTTP server
+	// Create the public HTTP server
 	server := &http.Server{
 		Addr:         ":" + port,
 		Handler:      handler,
@@ -75,45 +135,58 @@ func main() {
 		IdleTimeout:  30 * time.Second,
 	}
 
+	// Create the admin server: metrics/health/pprof, bound to a trusted
+	// interface and optionally behind Basic Auth, so scrape and debug
+	// traffic never competes with /hello for the public server's budgets.
+	adminHandler := middleware.BasicAuth(adminUser, adminPassword, newAdminMux(collector))
+	adminServer := &http.Server{
+		Addr:         adminBind + ":" + adminPort,
+		Handler:      adminHandler,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  30 * time.Second,
+	}
+
 	// Channel to listen for interrupt signals
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	// Start server in a goroutine
-	serverErrChan := make(chan error, 1)
+	// Start both servers in their own goroutines
+	serverErrChan := make(chan error, 2)
 	go func() {
-		stdoutLogger.Printf(`{"message":"server starting","addr":":%s"}`, port)
-		fileLogger.Printf(`{"message":"server starting","addr":":%s"}\n`, port)
+		log.Info("server starting", "addr", ":"+port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			serverErrChan <- err
 		}
 	}()
+	go func() {
+		log.Info("admin server starting", "addr", adminServer.Addr)
+		if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErrChan <- err
+		}
+	}()
 
 	// Wait for interrupt signal or server error
 	select {
 	case err := <-serverErrChan:
-		stdoutLogger.Fatalf(`{"message":"server error","error":"%v"}`, err)
+		log.Error("server error", "error", err)
+		os.Exit(1)
 	case sig := <-sigChan:
-		stdoutLogger.Printf(`{"message":"received signal","signal":"%v","shutting_down":true}`, sig)
-		fileLogger.Printf(`{"message":"received signal","signal":"%v","shutting_down":true}\n`, sig)
+		log.Info("received signal", "signal", sig.String(), "shutting_down", true)
 
-		// Create shutdown context with timeout
+		// Create shutdown context with timeout, shared by both servers
 		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer cancel()
 
-		// Graceful shutdown
-		if err := server.Shutdown(ctx); err != nil {
-			stdoutLogger.Printf(`{"message":"server shutdown error","error":"%v"}`, err)
-			fileLogger.Printf(`{"message":"server shutdown error","error":"%v"}\n`, err)
-			server.Close()
-		} else {
-			stdoutLogger.Println(`{"message":"server shutdown gracefully"}`)
-			fileLogger.Printf(`{"message":"server shutdown gracefully"}\n`)
-		}
+		// Graceful shutdown, in parallel
+		shutdownAll(ctx, log, map[string]*http.Server{
+			"public": server,
+			"admin":  adminServer,
+		})
 
 		// Final sync of log file
 		if err := logFile.Sync(); err != nil {
-			stdoutLogger.Printf(`{"message":"failed to sync log file on shutdown","error":"%v"}`, err)
+			log.Error("failed to sync log file on shutdown", "error", err)
 		}
 	}
 }
@@ -55,7 +55,7 @@ func TestTracePackage(t *testing.T) {
 func TestLoggerPackage(t *testing.T) {
 	var buf strings.Builder
 	logger := logger.New(&buf, "[TEST] ")
-	logger.Println("test message")
+	logger.Info("test message")
 
 	output := buf.String()
 	if !strings.Contains(output, "[TEST]") {
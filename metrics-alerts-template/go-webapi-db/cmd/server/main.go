@@ -2,65 +2,231 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"go-webapi-db/internal/breaker"
 	"go-webapi-db/internal/config"
 	"go-webapi-db/internal/handler"
 	"go-webapi-db/internal/health"
+	"go-webapi-db/internal/logger"
 	"go-webapi-db/internal/metrics"
+	"go-webapi-db/internal/metrics/dashboard"
 	"go-webapi-db/internal/middleware"
 	"go-webapi-db/internal/repository"
 	"go-webapi-db/internal/service"
+	"go-webapi-db/internal/trace"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// App owns the process-wide Prometheus registry and the TransactionalGatherer
+// that serves /metrics from a cached snapshot, so concurrent scrapes under
+// high scrape frequency share one collection pass instead of each re-walking
+// every registered Collector.
+type App struct {
+	Registry  *prometheus.Registry
+	UsersStat *metrics.UsersStatAggregator
+	gatherer  *metrics.TransactionalGatherer
+}
+
+// NewApp wraps registry in a TransactionalGatherer refreshed every
+// refreshInterval, and registers a UsersStatAggregator capped at
+// maxTrackedUsers distinct users.
+func NewApp(registry *prometheus.Registry, refreshInterval time.Duration, maxTrackedUsers int) *App {
+	usersStat := metrics.NewUsersStatAggregator(maxTrackedUsers)
+	registry.MustRegister(usersStat)
+
+	gatherer := metrics.NewTransactionalGatherer(registry)
+	gatherer.Start(refreshInterval)
+	return &App{Registry: registry, UsersStat: usersStat, gatherer: gatherer}
+}
+
+// Stop ends the gatherer's periodic refresh loop.
+func (a *App) Stop() {
+	a.gatherer.Stop()
+}
+
+// MetricsHandler serves /metrics from the TransactionalGatherer's cached
+// snapshot rather than collecting fresh on every request. EnableOpenMetrics
+// lets promhttp negotiate the OpenMetrics exposition format (which carries
+// exemplars) when a scraper sends Accept: application/openmetrics-text,
+// falling back to the classic Prometheus text format otherwise.
+func (a *App) MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(a.gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// basicAuthHandler wraps next with HTTP Basic Auth, comparing credentials in
+// constant time so a failed attempt can't be timed to learn which part
+// (user vs. pass) was wrong.
+type basicAuthHandler struct {
+	user, pass string
+	next       http.Handler
+}
+
+func (h *basicAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, pass, ok := r.BasicAuth()
+	if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(h.user)) != 1 || subtle.ConstantTimeCompare([]byte(pass), []byte(h.pass)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// metricsHandler builds the handler served on the dedicated metrics port,
+// wrapping app.MetricsHandler() in basicAuthHandler when both
+// cfg.Metrics.BasicUser and cfg.Metrics.BasicPass are configured.
+func metricsHandler(cfg *config.Config, app *App) http.Handler {
+	h := app.MetricsHandler()
+	if cfg.Metrics.BasicUser != "" && cfg.Metrics.BasicPass != "" {
+		h = &basicAuthHandler{user: cfg.Metrics.BasicUser, pass: cfg.Metrics.BasicPass, next: h}
+	}
+	return h
+}
+
 func main() {
-	cfg := config.Load()
+	cfg := config.LoadConfig()
+
+	log := logger.New(os.Stdout, logger.Options{Level: logger.LevelFromEnv()})
+	slog.SetDefault(log)
+
+	// liveCfg lets config.Watch hand reload callbacks a fresh *config.Config
+	// without restarting the process; cfg itself stays the one-time
+	// snapshot everything below was built from. Only knobs that are read
+	// fresh on every use (middleware.SetURICardinalityLimit's package
+	// global, ConfigForService's per-call config.GetFloat/GetDuration
+	// lookups) actually pick up a reload - values baked into long-lived
+	// objects at startup (e.g. srv.ReadTimeout) don't.
+	var liveCfg atomic.Pointer[config.Config]
+	liveCfg.Store(cfg)
+	if configFile := os.Getenv("CONFIG_FILE"); configFile != "" {
+		onReload := func(old, new *config.Config) {
+			middleware.SetURICardinalityLimit(new.Metrics.MaxURICardinality)
+			log.Info("config reloaded", "source", configFile)
+		}
+		if err := config.Watch(context.Background(), configFile, &liveCfg, onReload,
+			config.FileSource{Path: configFile}, config.EnvSource{}, config.FlagSource{Args: os.Args[1:]},
+		); err != nil {
+			log.Error("failed to start config watcher", "error", err)
+		}
+	}
+
+	shutdownTracer, err := trace.InitTracer(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Error("failed to initialize tracer", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracer(context.Background()); err != nil {
+			log.Error("failed to shut down tracer", "error", err)
+		}
+	}()
+
+	// A registry dedicated to the metrics port, not prometheus.DefaultRegisterer,
+	// so the port only ever serves what main.go explicitly registers below -
+	// not whatever some unrelated init() happened to add to the ambient
+	// global registry. Collectors built with the package-level promauto
+	// default (middleware.httpRequestsTotal and its kin) still register onto
+	// prometheus.DefaultRegisterer at their own package's init time, before
+	// this registry exists, so they're not reachable from here yet; moving
+	// those call sites onto promauto.With(registry) is a follow-up.
+	registry := prometheus.NewRegistry()
+
+	app := NewApp(registry, 5*time.Second, cfg.Metrics.MaxTrackedUsers)
+	defer app.Stop()
+
+	// MongoMetrics is registered against the same dedicated registry as
+	// everything else on the metrics port, rather than the deprecated
+	// package-level vars in metrics.RecordOperation and friends.
+	mongoMetrics := metrics.NewMongoMetrics(registry).WithLogger(log)
 
 	// Initialize MongoDB connection
-	mongoClient, err := connectMongoDB(cfg)
+	mongoClient, dbStats, err := connectMongoDB(cfg, mongoMetrics)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		log.Error("failed to connect to MongoDB", "error", err)
+		os.Exit(1)
 	}
 	defer mongoClient.Disconnect(context.Background())
+	registry.MustRegister(dbStats)
 
 	db := mongoClient.Database(cfg.MongoDB.Database)
 
 	// Initialize MongoDB metrics collector
-	mongoMetricsCollector := metrics.NewMongoDBMetricsCollector(mongoClient, cfg.MongoDB.Database, "go-webapi-db")
+	mongoMetricsCollector := metrics.NewMongoDBMetricsCollector(mongoClient, cfg.MongoDB.Database, "go-webapi-db").WithLogger(log)
 	mongoMetricsCollector.Start(10 * time.Second) // Collect metrics every 10 seconds
 	defer mongoMetricsCollector.Stop()
-	
+
 	// Set connection pool configuration metrics
-	metrics.SetConnectionPoolConfig("go-webapi-db", cfg.MongoDB.Database, cfg.MongoDB.MaxPoolSize, cfg.MongoDB.MinPoolSize)
+	mongoMetrics.SetConnectionPoolConfig("go-webapi-db", cfg.MongoDB.Database, cfg.MongoDB.MaxPoolSize, cfg.MongoDB.MinPoolSize)
 
-	// Initialize services
-	metricsService := service.NewMetricsService()
+	// Surface slow in-progress queries via currentOp, also logging each one
+	// found through a dedup handler so a persistently slow collection
+	// doesn't flood the log.
+	errorDedup := metrics.NewErrorDedupHandler(log.Handler(), time.Minute)
+	errorDedup.Start(time.Minute)
+	defer errorDedup.Stop()
+	slowOpLogger := slog.New(errorDedup)
+
+	slowOpCollector := metrics.NewSlowOpCollector(mongoClient, "go-webapi-db", 100*time.Millisecond).WithLogger(slowOpLogger)
+	slowOpCollector.Start(10 * time.Second)
+	defer slowOpCollector.Stop()
+
+	metricsExporter, shutdownExporter, err := buildMetricsExporter(context.Background(), cfg, registry)
+	if err != nil {
+		log.Error("failed to initialize metrics exporter", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownExporter()
+	if cfg.Metrics.Exporter != "prometheus" {
+		// RecordOperation (the deprecated package-level API, still used by a
+		// few call sites) only knows how to write to the package's
+		// Prometheus vecs; give it the active exporter too so a non-default
+		// METRICS_EXPORTER still sees MongoDB operation metrics.
+		metrics.SetActiveExporter(metricsExporter)
+	}
+
+	activeUsersCollector := metrics.NewActiveUsersCollector(config.GetDuration("ACTIVE_USER_WINDOW", time.Hour)).WithLogger(log)
+	activeUsersCollector.Start(30 * time.Second)
+	defer activeUsersCollector.Stop()
+
+	metricsService := service.NewMetricsService(service.Options{
+		Exporter:    metricsExporter,
+		Application: "go-webapi-db",
+		ActiveUsers: activeUsersCollector,
+	})
 	userRepo := repository.NewUserRepository(db)
 	instrumentedRepo := repository.NewInstrumentedUserRepository(userRepo)
-	userService := service.NewUserService(instrumentedRepo, metricsService)
+	breakerRegistry := breaker.NewRegistry()
+	userService := service.NewUserService(instrumentedRepo, metricsService, breakerRegistry)
 
 	// Initialize handlers
 	userHandler := handler.NewUserHandler(userService)
-	healthHandler := health.NewHealthHandler(db)
+	healthHandler := health.NewHealthHandler(db, registry)
+	healthHandler.Start(10 * time.Second)
+	defer healthHandler.Stop()
 
 	// Register Go runtime metrics
-	prometheus.MustRegister(prometheus.NewGoCollector())
-	prometheus.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	registry.MustRegister(prometheus.NewGoCollector())
+	registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+
+	// RED metrics registry for HTTP traffic, namespaced per MetricsConfig.
+	// middleware.MetricsMiddleware remains the primary collector for now;
+	// this registry is the target for the ongoing Prometheus consolidation.
+	metrics.NewRegistry(registry, cfg.Metrics.Namespace)
 
 	// Setup router
-	router := setupRouter(cfg, userHandler, healthHandler)
+	router := setupRouter(cfg, userHandler, healthHandler, metricsExporter, app)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -70,11 +236,48 @@ func main() {
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
 
+	// DatastoreCollector issues its MongoDB queries inline from Collect, so
+	// it gets its own Registry and route rather than joining registry/
+	// app.gatherer's cached snapshot - a slow or contended query here must
+	// never stall a /metrics scrape of the fast RED metrics.
+	dbRegistry := prometheus.NewRegistry()
+	dbRegistry.MustRegister(metrics.NewDatastoreCollector(db, cfg.Metrics.DBQueryTimeout).WithLogger(log))
+	dbMetricsHandler := promhttp.HandlerFor(dbRegistry, promhttp.HandlerOpts{})
+	if cfg.Metrics.BasicUser != "" && cfg.Metrics.BasicPass != "" {
+		dbMetricsHandler = &basicAuthHandler{user: cfg.Metrics.BasicUser, pass: cfg.Metrics.BasicPass, next: dbMetricsHandler}
+	}
+
+	// Metrics server: a second listener dedicated to cfg.Metrics.Path, kept
+	// off the public API's listener so scrape traffic (and its own
+	// TLS/Basic Auth) never touches router.
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle(cfg.Metrics.Path, metricsHandler(cfg, app))
+	metricsMux.Handle(cfg.Metrics.DBPath, dbMetricsHandler)
+	metricsSrv := &http.Server{
+		Addr:    ":" + cfg.Metrics.Port,
+		Handler: metricsMux,
+	}
+
 	// Start server in goroutine
 	go func() {
-		log.Printf("Server starting on port %s", cfg.Server.Port)
+		log.Info("server starting", "port", cfg.Server.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			log.Error("failed to start server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	go func() {
+		log.Info("metrics server starting", "port", cfg.Metrics.Port, "tls", cfg.Metrics.TLSCert != "", "basic_auth", cfg.Metrics.BasicUser != "")
+		var err error
+		if cfg.Metrics.TLSCert != "" && cfg.Metrics.TLSKey != "" {
+			err = metricsSrv.ListenAndServeTLS(cfg.Metrics.TLSCert, cfg.Metrics.TLSKey)
+		} else {
+			err = metricsSrv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Error("failed to start metrics server", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -83,53 +286,157 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	log.Info("shutting down server")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Error("server forced to shutdown", "error", err)
+		os.Exit(1)
+	}
+	if err := metricsSrv.Shutdown(ctx); err != nil {
+		log.Error("metrics server forced to shutdown", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Server exited")
+	log.Info("server exited")
 }
 
-func connectMongoDB(cfg *config.Config) (*mongo.Client, error) {
+func connectMongoDB(cfg *config.Config, mongoMetrics *metrics.MongoMetrics) (*mongo.Client, *metrics.DBStatsCollector, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.MongoDB.ConnectTimeout)
 	defer cancel()
 
-	opts := options.Client().
-		ApplyURI(cfg.MongoDB.URI).
+	opts, dbStats := metrics.NewMonitoredClientOptions("go-webapi-db", cfg.MongoDB.Database, mongoMetrics)
+	opts.ApplyURI(cfg.MongoDB.URI).
 		SetMaxPoolSize(cfg.MongoDB.MaxPoolSize).
 		SetMinPoolSize(cfg.MongoDB.MinPoolSize)
 
 	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+		return nil, nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
 
 	// Ping to verify connection
 	if err := client.Ping(ctx, nil); err != nil {
-		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+		return nil, nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
-	log.Println("Connected to MongoDB successfully")
-	return client, nil
+	slog.Info("connected to MongoDB successfully")
+	return client, dbStats, nil
+}
+
+// buildMetricsExporter selects and constructs the metrics.Exporter backend
+// named by cfg.Metrics.Exporter, returning a shutdown func that's always
+// safe to defer (a no-op for the "prometheus" default, which needs no
+// background push loop).
+func buildMetricsExporter(ctx context.Context, cfg *config.Config, registry *prometheus.Registry) (metrics.Exporter, func(), error) {
+	noop := func() {}
+
+	switch cfg.Metrics.Exporter {
+	case "datadog":
+		exporter, err := metrics.NewDogStatsDExporter("go-webapi-db", cfg.Metrics.DatadogAddress, cfg.Metrics.DatadogPushInterval)
+		if err != nil {
+			return nil, noop, fmt.Errorf("failed to initialize datadog exporter: %w", err)
+		}
+		return exporter, exporter.Stop, nil
+
+	case "otlp":
+		exporter, err := metrics.NewOTLPExporter(ctx, "go-webapi-db", cfg.Metrics.OTLPEndpoint, cfg.Metrics.OTLPPushInterval)
+		if err != nil {
+			return nil, noop, fmt.Errorf("failed to initialize otlp exporter: %w", err)
+		}
+		return exporter, func() { exporter.Shutdown(context.Background()) }, nil
+
+	case "multi":
+		exporters := []metrics.Exporter{metrics.NewPrometheusExporter(registry)}
+		shutdowns := []func(){}
+
+		if cfg.Metrics.DatadogAddress != "" {
+			datadog, err := metrics.NewDogStatsDExporter("go-webapi-db", cfg.Metrics.DatadogAddress, cfg.Metrics.DatadogPushInterval)
+			if err != nil {
+				return nil, noop, fmt.Errorf("failed to initialize datadog exporter: %w", err)
+			}
+			exporters = append(exporters, datadog)
+			shutdowns = append(shutdowns, datadog.Stop)
+		}
+		if cfg.Metrics.OTLPEndpoint != "" {
+			otlp, err := metrics.NewOTLPExporter(ctx, "go-webapi-db", cfg.Metrics.OTLPEndpoint, cfg.Metrics.OTLPPushInterval)
+			if err != nil {
+				return nil, noop, fmt.Errorf("failed to initialize otlp exporter: %w", err)
+			}
+			exporters = append(exporters, otlp)
+			shutdowns = append(shutdowns, func() { otlp.Shutdown(context.Background()) })
+		}
+
+		return metrics.NewMultiExporter(exporters...), func() {
+			for _, shutdown := range shutdowns {
+				shutdown()
+			}
+		}, nil
+
+	default:
+		return metrics.NewPrometheusExporter(registry), noop, nil
+	}
 }
 
-func setupRouter(cfg *config.Config, userHandler *handler.UserHandler, healthHandler *health.HealthHandler) *gin.Engine {
+func setupRouter(cfg *config.Config, userHandler *handler.UserHandler, healthHandler *health.HealthHandler, metricsExporter metrics.Exporter, app *App) *gin.Engine {
 	router := gin.Default()
 
+	middleware.SetURICardinalityLimit(cfg.Metrics.MaxURICardinality)
+
+	// routeRegistry is populated below, once every router.GET/POST/etc call
+	// in this function has run - NewMetricsMiddleware's closure only reads
+	// it at request-serving time, which is always later, so this ordering
+	// is safe despite Use() being called before the routes it labels exist.
+	routeRegistry := middleware.NewRouteRegistry()
+
 	// Middleware
 	router.Use(middleware.RecoveryMiddleware())
-	router.Use(middleware.MetricsMiddleware())
+	router.Use(trace.GinMiddleware())
+	if cfg.Metrics.Exporter == "prometheus" {
+		router.Use(middleware.NewMetricsMiddleware(routeRegistry, app.Registry))
+	} else {
+		router.Use(middleware.NewExporterMetricsMiddleware(metricsExporter))
+	}
+	router.Use(middleware.UsersStatMiddleware(app.UsersStat))
 
-	// Health check endpoint
-	router.GET("/health", healthHandler.HealthCheck)
+	// Health check endpoints
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.GET("/healthz", healthHandler.Healthz)
 
-	// Metrics endpoint
-	router.GET(cfg.Metrics.Path, gin.WrapH(promhttp.Handler()))
+	// /metrics itself is no longer served here - see the dedicated metrics
+	// http.Server started in main(), on its own port with optional TLS and
+	// Basic Auth, so scrape traffic never shares a listener with the API.
+
+	if cfg.Metrics.DashboardEnabled {
+		router.GET("/internal/dashboard.json", func(c *gin.Context) {
+			dashboardJSON, _, err := dashboard.Generate(app.Registry)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.Data(http.StatusOK, "application/json", dashboardJSON)
+		})
+		router.GET("/internal/rules.yaml", func(c *gin.Context) {
+			_, rulesYAML, err := dashboard.Generate(app.Registry)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.Data(http.StatusOK, "application/yaml", rulesYAML)
+		})
+		router.GET("/internal/cardinality", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"top": middleware.CardinalityTopN(20)})
+		})
+		// /debug/user-metrics gives operators without a Prometheus stack
+		// the same per-user traffic UsersStatMiddleware feeds into
+		// user_requests_total/user_bytes_in_total/user_bytes_out_total.
+		router.GET("/debug/user-metrics", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"users": app.UsersStat.Snapshot()})
+		})
+	}
 
 	// API routes
 	api := router.Group("/api/users")
@@ -147,6 +454,7 @@ func setupRouter(cfg *config.Config, userHandler *handler.UserHandler, healthHan
 		api.GET("/test/slow", userHandler.TriggerSlowResponse)
 	}
 
+	routeRegistry.RegisterGinRoutes(router.Routes())
+
 	return router
 }
-
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -27,7 +28,7 @@ func TestMetricsEndpoint_AllMetricsExported(t *testing.T) {
 	prometheus.DefaultRegisterer = reg
 	prometheus.DefaultGatherer = reg
 
-	cfg := config.Load()
+	cfg := config.LoadConfig()
 
 	// Setup router similar to main.go
 	router := gin.New()
@@ -35,7 +36,7 @@ func TestMetricsEndpoint_AllMetricsExported(t *testing.T) {
 	router.Use(middleware.MetricsMiddleware())
 
 	// Create services
-	metricsService := service.NewMetricsService()
+	metricsService := service.NewMetricsService(service.Options{Registry: reg})
 
 	// Create mock handlers - we don't need full functionality for metrics testing
 	// Just need to ensure routes exist to generate HTTP metrics
@@ -44,7 +45,7 @@ func TestMetricsEndpoint_AllMetricsExported(t *testing.T) {
 	})
 
 	// Create mock health handler (nil db is OK for testing - it will just report DOWN)
-	healthHandler := health.NewHealthHandler(nil)
+	healthHandler := health.NewHealthHandler(nil, prometheus.NewRegistry())
 
 	// Register routes
 	router.GET("/health", healthHandler.HealthCheck)
@@ -133,7 +134,7 @@ func TestMetricsEndpoint_Format(t *testing.T) {
 	prometheus.DefaultRegisterer = reg
 	prometheus.DefaultGatherer = reg
 
-	cfg := config.Load()
+	cfg := config.LoadConfig()
 	router := gin.New()
 	router.GET(cfg.Metrics.Path, gin.WrapH(promhttp.Handler()))
 
@@ -165,7 +166,9 @@ func TestMetricsEndpoint_Format(t *testing.T) {
 	}
 }
 
-// TestMetricsEndpoint_ContentType verifies correct content type
+// TestMetricsEndpoint_ContentType verifies correct content type, both the
+// default Prometheus text format and, when the client negotiates for it via
+// Accept, the OpenMetrics format that carries exemplars.
 func TestMetricsEndpoint_ContentType(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -173,9 +176,9 @@ func TestMetricsEndpoint_ContentType(t *testing.T) {
 	prometheus.DefaultRegisterer = reg
 	prometheus.DefaultGatherer = reg
 
-	cfg := config.Load()
+	cfg := config.LoadConfig()
 	router := gin.New()
-	router.GET(cfg.Metrics.Path, gin.WrapH(promhttp.Handler()))
+	router.GET(cfg.Metrics.Path, gin.WrapH(promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true})))
 
 	req, _ := http.NewRequest("GET", cfg.Metrics.Path, nil)
 	w := httptest.NewRecorder()
@@ -187,6 +190,18 @@ func TestMetricsEndpoint_ContentType(t *testing.T) {
 	if contentType != expectedContentType {
 		t.Errorf("Expected Content-Type '%s', got '%s'", expectedContentType, contentType)
 	}
+
+	omReq, _ := http.NewRequest("GET", cfg.Metrics.Path, nil)
+	omReq.Header.Set("Accept", "application/openmetrics-text")
+	omW := httptest.NewRecorder()
+	router.ServeHTTP(omW, omReq)
+
+	omContentType := omW.Header().Get("Content-Type")
+	expectedOMPrefix := "application/openmetrics-text; version=1.0.0"
+
+	if !strings.HasPrefix(omContentType, expectedOMPrefix) {
+		t.Errorf("Expected Content-Type to start with '%s', got '%s'", expectedOMPrefix, omContentType)
+	}
 }
 
 // TestAllExpectedMetrics verifies comprehensive list of expected metrics
@@ -197,7 +212,7 @@ func TestAllExpectedMetrics(t *testing.T) {
 	prometheus.DefaultRegisterer = reg
 	prometheus.DefaultGatherer = reg
 
-	cfg := config.Load()
+	cfg := config.LoadConfig()
 	router := gin.New()
 	router.Use(middleware.MetricsMiddleware())
 	router.GET(cfg.Metrics.Path, gin.WrapH(promhttp.Handler()))
@@ -206,14 +221,14 @@ func TestAllExpectedMetrics(t *testing.T) {
 	})
 
 	// Exercise all metric types
-	metricsService := service.NewMetricsService()
+	metricsService := service.NewMetricsService(service.Options{Registry: reg})
 	metricsService.IncrementUserCreated()
 	metricsService.IncrementUserUpdated()
 	metricsService.IncrementUserDeleted()
 	stopTimer := metricsService.StartUserOperationTimer()
 	stopTimer()
 	metricsService.IncrementUserOperationErrors("test")
-	metricsService.RecordExternalCallDuration("test-service", time.Duration(0))
+	metricsService.RecordExternalCallDuration(context.Background(), "test-service", time.Duration(0))
 	metricsService.IncrementExternalCallErrors("test-service")
 
 	// Initialize MongoDB metrics (set connection pool config)
@@ -285,7 +300,7 @@ func TestMetricsLabels(t *testing.T) {
 	prometheus.DefaultRegisterer = reg
 	prometheus.DefaultGatherer = reg
 
-	cfg := config.Load()
+	cfg := config.LoadConfig()
 	router := gin.New()
 	router.Use(middleware.MetricsMiddleware())
 	router.GET(cfg.Metrics.Path, gin.WrapH(promhttp.Handler()))
@@ -293,7 +308,7 @@ func TestMetricsLabels(t *testing.T) {
 		c.JSON(200, gin.H{"id": "123"})
 	})
 
-	metricsService := service.NewMetricsService()
+	metricsService := service.NewMetricsService(service.Options{Registry: reg})
 	metricsService.IncrementUserCreated()
 	metricsService.IncrementUserOperationErrors("not_found")
 
@@ -0,0 +1,257 @@
+// Package breaker implements a per-service circuit breaker over a rolling
+// failure-ratio window, for guarding calls to flaky external dependencies.
+package breaker
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webapi-db/internal/config"
+)
+
+// State is a circuit breaker's lifecycle state.
+type State int
+
+const (
+	// Closed allows all requests through and trips to Open once the
+	// failure ratio breaches the configured threshold.
+	Closed State = iota
+	// Open short-circuits every request until CooldownPeriod elapses.
+	Open
+	// HalfOpen allows a single probe request through to decide whether to
+	// return to Closed (on success) or back to Open (on failure).
+	HalfOpen
+)
+
+// String renders the state for logs and metric labels.
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// ErrCircuitOpen is returned by Registry.Allow when a service's breaker is
+// open and still within its cooldown period, or half-open with its probe
+// request still in flight.
+var ErrCircuitOpen = errors.New("breaker: circuit is open")
+
+// Config configures a single service's breaker.
+type Config struct {
+	// FailureThreshold is the ratio (0,1] of failed requests within Window
+	// that trips the breaker from Closed to Open.
+	FailureThreshold float64
+	// MinRequestVolume is the minimum number of requests observed in Window
+	// before FailureThreshold is evaluated, so a handful of early failures
+	// can't trip the breaker on their own.
+	MinRequestVolume int64
+	// Window is the total rolling duration over which failures are counted.
+	Window time.Duration
+	// BucketSize is the width of each bucket within Window. Buckets older
+	// than Window are dropped as time advances.
+	BucketSize time.Duration
+	// CooldownPeriod is how long an Open breaker waits before allowing a
+	// single HalfOpen probe request through.
+	CooldownPeriod time.Duration
+}
+
+// DefaultConfig is a conservative default: a 50% failure ratio over a 60s
+// window (tracked in 10s buckets) trips the breaker, with a 30s cooldown
+// before the next probe.
+func DefaultConfig() Config {
+	return Config{
+		FailureThreshold: 0.5,
+		MinRequestVolume: 10,
+		Window:           60 * time.Second,
+		BucketSize:       10 * time.Second,
+		CooldownPeriod:   30 * time.Second,
+	}
+}
+
+// ConfigForService returns DefaultConfig with FailureThreshold and Window
+// overridden by BREAKER_<SERVICE>_THRESHOLD / BREAKER_<SERVICE>_WINDOW, where
+// <SERVICE> is serviceName upper-cased, if those env vars are set.
+func ConfigForService(serviceName string) Config {
+	cfg := DefaultConfig()
+	prefix := "BREAKER_" + strings.ToUpper(serviceName) + "_"
+	cfg.FailureThreshold = config.GetFloat(prefix+"THRESHOLD", cfg.FailureThreshold)
+	cfg.Window = config.GetDuration(prefix+"WINDOW", cfg.Window)
+	return cfg
+}
+
+// bucket tallies successes/failures within a single BucketSize-wide slot of
+// the rolling window.
+type bucket struct {
+	start     time.Time
+	successes int64
+	failures  int64
+}
+
+// breaker is a single service's circuit breaker state.
+type breaker struct {
+	mu       sync.Mutex
+	cfg      Config
+	state    State
+	buckets  []bucket
+	openedAt time.Time
+}
+
+func newBreaker(cfg Config) *breaker {
+	return &breaker{cfg: cfg, state: Closed}
+}
+
+// allow reports whether a request may proceed, advancing Open to HalfOpen
+// once the cooldown has elapsed. The request that makes that transition is
+// the probe; every other request is rejected until markSuccess or
+// markFailure records the probe's outcome.
+func (b *breaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trim(now)
+
+	if b.state == HalfOpen {
+		return ErrCircuitOpen
+	}
+	if b.state == Open {
+		if now.Sub(b.openedAt) < b.cfg.CooldownPeriod {
+			return ErrCircuitOpen
+		}
+		b.state = HalfOpen
+	}
+	return nil
+}
+
+// markSuccess records a successful call, closing the breaker if it was
+// probing from HalfOpen.
+func (b *breaker) markSuccess(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trim(now)
+	b.currentBucket(now).successes++
+
+	if b.state == HalfOpen {
+		b.state = Closed
+		b.buckets = nil
+	}
+}
+
+// markFailure records a failed call, tripping the breaker open either
+// immediately (a failed HalfOpen probe) or once the rolling failure ratio
+// breaches cfg.FailureThreshold over at least cfg.MinRequestVolume requests.
+func (b *breaker) markFailure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trim(now)
+	b.currentBucket(now).failures++
+
+	if b.state == HalfOpen {
+		b.trip(now)
+		return
+	}
+	if b.state == Closed {
+		successes, failures := b.totals()
+		total := successes + failures
+		if total >= b.cfg.MinRequestVolume && float64(failures)/float64(total) >= b.cfg.FailureThreshold {
+			b.trip(now)
+		}
+	}
+}
+
+func (b *breaker) trip(now time.Time) {
+	b.state = Open
+	b.openedAt = now
+}
+
+func (b *breaker) currentState() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// currentBucket returns the bucket for now, appending a new one if the
+// rolling window has advanced past the last recorded bucket.
+func (b *breaker) currentBucket(now time.Time) *bucket {
+	start := now.Truncate(b.cfg.BucketSize)
+	if n := len(b.buckets); n > 0 && b.buckets[n-1].start.Equal(start) {
+		return &b.buckets[n-1]
+	}
+	b.buckets = append(b.buckets, bucket{start: start})
+	return &b.buckets[len(b.buckets)-1]
+}
+
+// trim drops buckets that have aged out of the rolling window.
+func (b *breaker) trim(now time.Time) {
+	cutoff := now.Add(-b.cfg.Window)
+	i := 0
+	for i < len(b.buckets) && b.buckets[i].start.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		b.buckets = b.buckets[i:]
+	}
+}
+
+func (b *breaker) totals() (successes, failures int64) {
+	for _, bu := range b.buckets {
+		successes += bu.successes
+		failures += bu.failures
+	}
+	return successes, failures
+}
+
+// Registry holds one breaker per external service name, created lazily on
+// first use with ConfigForService(name).
+type Registry struct {
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{breakers: make(map[string]*breaker)}
+}
+
+func (r *Registry) get(service string) *breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[service]
+	if !ok {
+		b = newBreaker(ConfigForService(service))
+		r.breakers[service] = b
+	}
+	return b
+}
+
+// Allow reports whether a call to service may proceed, returning
+// ErrCircuitOpen if that service's breaker is open and still cooling down,
+// or half-open with a probe call already allowed through.
+func (r *Registry) Allow(service string) error {
+	return r.get(service).allow(time.Now())
+}
+
+// MarkSuccess records a successful call to service.
+func (r *Registry) MarkSuccess(service string) {
+	r.get(service).markSuccess(time.Now())
+}
+
+// MarkFailure records a failed call to service, potentially tripping its
+// breaker open.
+func (r *Registry) MarkFailure(service string) {
+	r.get(service).markFailure(time.Now())
+}
+
+// State returns the current state of service's breaker (Closed if no calls
+// have been recorded for it yet).
+func (r *Registry) State(service string) State {
+	return r.get(service).currentState()
+}
@@ -0,0 +1,109 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		FailureThreshold: 0.5,
+		MinRequestVolume: 4,
+		Window:           time.Minute,
+		BucketSize:       time.Second,
+		CooldownPeriod:   10 * time.Second,
+	}
+}
+
+func TestBreaker_TripsOpenOnFailureRatio(t *testing.T) {
+	b := newBreaker(testConfig())
+	now := time.Now()
+
+	b.markSuccess(now)
+	b.markFailure(now)
+	b.markFailure(now)
+	b.markFailure(now)
+
+	if got := b.currentState(); got != Open {
+		t.Fatalf("expected Open after breaching threshold, got %v", got)
+	}
+	if err := b.allow(now); err != ErrCircuitOpen {
+		t.Fatalf("expected ErrCircuitOpen while open, got %v", err)
+	}
+}
+
+func TestBreaker_StaysClosedBelowMinVolume(t *testing.T) {
+	b := newBreaker(testConfig())
+	now := time.Now()
+
+	b.markFailure(now)
+	b.markFailure(now)
+
+	if got := b.currentState(); got != Closed {
+		t.Fatalf("expected Closed below MinRequestVolume, got %v", got)
+	}
+}
+
+func TestBreaker_HalfOpenProbeSuccessCloses(t *testing.T) {
+	cfg := testConfig()
+	b := newBreaker(cfg)
+	now := time.Now()
+
+	b.markFailure(now)
+	b.markFailure(now)
+	b.markFailure(now)
+	b.markFailure(now)
+	if got := b.currentState(); got != Open {
+		t.Fatalf("expected Open, got %v", got)
+	}
+
+	probeTime := now.Add(cfg.CooldownPeriod + time.Millisecond)
+	if err := b.allow(probeTime); err != nil {
+		t.Fatalf("expected probe to be allowed after cooldown, got %v", err)
+	}
+	if got := b.currentState(); got != HalfOpen {
+		t.Fatalf("expected HalfOpen after cooldown, got %v", got)
+	}
+
+	b.markSuccess(probeTime)
+	if got := b.currentState(); got != Closed {
+		t.Fatalf("expected Closed after successful probe, got %v", got)
+	}
+}
+
+func TestBreaker_HalfOpenProbeFailureReopens(t *testing.T) {
+	cfg := testConfig()
+	b := newBreaker(cfg)
+	now := time.Now()
+
+	b.markFailure(now)
+	b.markFailure(now)
+	b.markFailure(now)
+	b.markFailure(now)
+
+	probeTime := now.Add(cfg.CooldownPeriod + time.Millisecond)
+	_ = b.allow(probeTime)
+	b.markFailure(probeTime)
+
+	if got := b.currentState(); got != Open {
+		t.Fatalf("expected Open after failed probe, got %v", got)
+	}
+	if err := b.allow(probeTime); err != ErrCircuitOpen {
+		t.Fatalf("expected ErrCircuitOpen immediately after reopening, got %v", err)
+	}
+}
+
+func TestRegistry_PerServiceIsolation(t *testing.T) {
+	r := NewRegistry()
+
+	for i := 0; i < 10; i++ {
+		r.MarkFailure("payments")
+	}
+
+	if got := r.State("payments"); got != Open {
+		t.Fatalf("expected payments breaker Open, got %v", got)
+	}
+	if got := r.State("inventory"); got != Closed {
+		t.Fatalf("expected inventory breaker unaffected (Closed), got %v", got)
+	}
+}
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Source is one layer of configuration values. Load applies sources in the
+// order they're passed, so a later source overrides an earlier one for any
+// key both define.
+type Source interface {
+	// Load returns this source's values keyed by the same name used in a
+	// struct field's `env` tag. A source with nothing to contribute (e.g. an
+	// unset CONFIG_FILE) returns a nil map, not an error.
+	Load() (map[string]string, error)
+}
+
+// EnvSource reads os.Environ(), so Load can resolve any `env` tag without
+// the caller enumerating keys up front.
+type EnvSource struct{}
+
+func (EnvSource) Load() (map[string]string, error) {
+	values := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			values[k] = v
+		}
+	}
+	return values, nil
+}
+
+// FileSource reads a flat JSON object of env-var-name keys from Path
+// (typically CONFIG_FILE). A missing file is not an error, so CONFIG_FILE
+// can be left unset and the file layer simply contributes nothing.
+type FileSource struct {
+	Path string
+}
+
+func (s FileSource) Load() (map[string]string, error) {
+	if s.Path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("config: reading %s: %w", s.Path, err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("config: parsing %s: %w", s.Path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		var str string
+		if err := json.Unmarshal(v, &str); err == nil {
+			values[k] = str
+			continue
+		}
+		values[k] = strings.Trim(string(v), `"`)
+	}
+	return values, nil
+}
+
+// FlagSource parses "-key=value" / "--key=value" command-line arguments
+// (typically os.Args[1:]) into env-var-name keys. It's meant to be the last
+// source passed to Load, so flags win over everything else.
+type FlagSource struct {
+	Args []string
+}
+
+func (s FlagSource) Load() (map[string]string, error) {
+	values := make(map[string]string)
+	for _, arg := range s.Args {
+		k, v, ok := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !ok {
+			continue
+		}
+		values[strings.ToUpper(k)] = v
+	}
+	return values, nil
+}
+
+// Load resolves a *T from sources (each overriding the previous) layered on
+// top of each field's `default` tag, then runs `validate` tags. Only fields
+// tagged with `env:"NAME"` participate; untagged struct fields (such as
+// Config.Server) are filled the same way, other untagged fields are left zero.
+//
+//	type ServerConfig struct {
+//	    Port    string        `env:"SERVER_PORT" default:"8080"`
+//	    Timeout time.Duration `env:"SERVER_TIMEOUT" default:"15s" validate:"min=1"`
+//	}
+func Load[T any](sources ...Source) (*T, error) {
+	var cfg T
+	v := reflect.ValueOf(&cfg).Elem()
+	t := v.Type()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config: Load target must be a struct, got %s", t.Kind())
+	}
+
+	values := make(map[string]string)
+	for _, src := range sources {
+		resolved, err := src.Load()
+		if err != nil {
+			return nil, err
+		}
+		for k, val := range resolved {
+			values[k] = val
+		}
+	}
+
+	if err := loadFields(v, values); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadFields fills v's `env`-tagged fields from values, descending into
+// untagged struct fields.
+func loadFields(v reflect.Value, values map[string]string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		envKey, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct && field.IsExported() {
+				if err := loadFields(v.Field(i), values); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		raw, present := values[envKey]
+		if !present {
+			raw, present = field.Tag.Lookup("default")
+		}
+		if present {
+			if err := setField(v.Field(i), raw); err != nil {
+				return fmt.Errorf("config: field %s: %w", field.Name, err)
+			}
+		}
+
+		if err := validateField(field.Name, v.Field(i), field.Tag.Get("validate")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setField(fv reflect.Value, raw string) error {
+	switch {
+	case fv.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+	case fv.Kind() == reflect.String:
+		fv.SetString(raw)
+	case fv.Kind() >= reflect.Int && fv.Kind() <= reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case fv.Kind() >= reflect.Uint && fv.Kind() <= reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case fv.Kind() == reflect.Float32 || fv.Kind() == reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case fv.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field kind %s", fv.Kind())
+	}
+	return nil
+}
+
+func validateField(name string, fv reflect.Value, tag string) error {
+	if tag == "" {
+		return nil
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		switch {
+		case rule == "required":
+			if fv.IsZero() {
+				return fmt.Errorf("config: field %s is required", name)
+			}
+		case strings.HasPrefix(rule, "min="):
+			min, err := strconv.ParseFloat(strings.TrimPrefix(rule, "min="), 64)
+			if err != nil {
+				return fmt.Errorf("config: invalid min rule on field %s: %w", name, err)
+			}
+			if numericValue(fv) < min {
+				return fmt.Errorf("config: field %s must be >= %v", name, min)
+			}
+		case strings.HasPrefix(rule, "max="):
+			max, err := strconv.ParseFloat(strings.TrimPrefix(rule, "max="), 64)
+			if err != nil {
+				return fmt.Errorf("config: invalid max rule on field %s: %w", name, err)
+			}
+			if numericValue(fv) > max {
+				return fmt.Errorf("config: field %s must be <= %v", name, max)
+			}
+		}
+	}
+	return nil
+}
+
+func numericValue(fv reflect.Value) float64 {
+	switch fv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return fv.Float()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint())
+	default:
+		return float64(fv.Int())
+	}
+}
+
+// Watch watches path (typically CONFIG_FILE) for writes and, on each one,
+// reloads sources into a fresh *T and atomically swaps it into current, so
+// a concurrent current.Load() from another goroutine never observes a
+// torn, half-written struct, then invokes onReload(old, new) so subsystems
+// (HTTP timeouts, breaker thresholds, ...) can pick up the change without a
+// restart. It runs until ctx is cancelled; a blank path is a no-op, since
+// there's nothing to watch.
+func Watch[T any](ctx context.Context, path string, current *atomic.Pointer[T], onReload func(old, new *T), sources ...Source) error {
+	if path == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("config: starting file watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("config: watching %s: %w", path, err)
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(path) {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+
+				updated, err := Load[T](sources...)
+				if err != nil {
+					continue
+				}
+				old := current.Swap(updated)
+				onReload(old, updated)
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
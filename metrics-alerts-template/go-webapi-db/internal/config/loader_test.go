@@ -0,0 +1,162 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Port    string        `env:"TEST_PORT" default:"8080"`
+	Timeout time.Duration `env:"TEST_TIMEOUT" default:"15s" validate:"min=1"`
+	Ratio   float64       `env:"TEST_RATIO" default:"0.5" validate:"min=0,max=1"`
+	Name    string        `env:"TEST_NAME" validate:"required"`
+}
+
+func TestLoad_DefaultsOnly(t *testing.T) {
+	os.Unsetenv("TEST_PORT")
+	os.Unsetenv("TEST_TIMEOUT")
+	os.Unsetenv("TEST_RATIO")
+	t.Setenv("TEST_NAME", "svc")
+
+	cfg, err := Load[testConfig](EnvSource{})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Port != "8080" {
+		t.Errorf("expected default port 8080, got %q", cfg.Port)
+	}
+	if cfg.Timeout != 15*time.Second {
+		t.Errorf("expected default timeout 15s, got %v", cfg.Timeout)
+	}
+}
+
+func TestLoad_EnvOverridesDefault(t *testing.T) {
+	t.Setenv("TEST_PORT", "9090")
+	t.Setenv("TEST_NAME", "svc")
+
+	cfg, err := Load[testConfig](EnvSource{})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Port != "9090" {
+		t.Errorf("expected env override 9090, got %q", cfg.Port)
+	}
+}
+
+func TestLoad_FileOverridesDefaultButNotEnv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"TEST_PORT":"7070","TEST_NAME":"from-file"}`), 0o644); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+
+	t.Setenv("TEST_NAME", "from-env")
+
+	cfg, err := Load[testConfig](FileSource{Path: path}, EnvSource{})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Port != "7070" {
+		t.Errorf("expected file value 7070, got %q", cfg.Port)
+	}
+	if cfg.Name != "from-env" {
+		t.Errorf("expected env to win over file, got %q", cfg.Name)
+	}
+}
+
+func TestLoad_RequiredValidationFails(t *testing.T) {
+	os.Unsetenv("TEST_NAME")
+
+	if _, err := Load[testConfig](EnvSource{}); err == nil {
+		t.Fatal("expected error for missing required field")
+	}
+}
+
+func TestLoad_MinMaxValidationFails(t *testing.T) {
+	t.Setenv("TEST_NAME", "svc")
+	t.Setenv("TEST_RATIO", "1.5")
+
+	if _, err := Load[testConfig](EnvSource{}); err == nil {
+		t.Fatal("expected error for TEST_RATIO exceeding max")
+	}
+}
+
+// TestWatch_ConcurrentReadsDuringReload reproduces the concurrency Watch
+// must support: readers calling current.Load() while a file write triggers
+// a reload. Before the atomic.Pointer[T] fix, the reload swapped *current
+// in place with two unsynchronized writes, which -race flags as a data
+// race against any concurrent reader of its fields.
+func TestWatch_ConcurrentReadsDuringReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"TEST_PORT":"7070","TEST_NAME":"from-file"}`), 0o644); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+	t.Setenv("TEST_NAME", "from-env")
+
+	initial, err := Load[testConfig](FileSource{Path: path}, EnvSource{})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	var current atomic.Pointer[testConfig]
+	current.Store(initial)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var reloaded atomic.Bool
+	onReload := func(old, new *testConfig) { reloaded.Store(true) }
+
+	if err := Watch(ctx, path, &current, onReload, FileSource{Path: path}, EnvSource{}); err != nil {
+		t.Fatalf("Watch returned error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = current.Load().Port
+				}
+			}
+		}()
+	}
+
+	if err := os.WriteFile(path, []byte(`{"TEST_PORT":"8080","TEST_NAME":"from-file"}`), 0o644); err != nil {
+		t.Fatalf("rewriting config file: %v", err)
+	}
+
+	deadline := time.After(2 * time.Second)
+	for !reloaded.Load() {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for Watch to reload after a file write")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+}
+
+func TestFlagSource_Load(t *testing.T) {
+	src := FlagSource{Args: []string{"--test_port=6060", "not-a-flag"}}
+	values, err := src.Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if values["TEST_PORT"] != "6060" {
+		t.Errorf("expected TEST_PORT=6060, got %q", values["TEST_PORT"])
+	}
+}
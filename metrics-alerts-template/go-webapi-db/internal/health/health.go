@@ -1,79 +1,206 @@
+// Package health provides liveness, readiness, and detailed dependency
+// health checks for the service.
 package health
 
 import (
 	"context"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
-	"go-webapi-db/internal/metrics"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var (
-	healthStatus = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "health_status",
-			Help: "Health status of various components (1 = healthy, 0 = unhealthy)",
-		},
-		[]string{"component"},
-	)
-)
+// Checker is a dependency whose health can be probed. Checkers are
+// registered with a HealthHandler via Register.
+type Checker interface {
+	// Name identifies the checker, used as the component label and JSON key.
+	Name() string
+	// Critical reports whether a failing check should fail readiness.
+	Critical() bool
+	// Check probes the dependency, returning a non-nil error if unhealthy.
+	Check(ctx context.Context) error
+}
+
+type checkResult struct {
+	err     error
+	latency time.Duration
+	lastOK  time.Time
+}
 
+// HealthHandler serves liveness, readiness, and detailed health endpoints
+// backed by a set of registered Checkers. Checks run periodically in a
+// background goroutine so the HTTP handlers always return cached results
+// instantly instead of blocking on live probes.
 type HealthHandler struct {
-	db *mongo.Database
+	mu       sync.RWMutex
+	checkers []Checker
+	results  map[string]checkResult
+	stop     chan struct{}
+
+	healthStatus  *prometheus.GaugeVec
+	checkDuration *prometheus.HistogramVec
 }
 
-func NewHealthHandler(db *mongo.Database) *HealthHandler {
-	return &HealthHandler{
-		db: db,
+// NewHealthHandler creates a HealthHandler whose health_status and
+// health_check_duration_seconds series are registered against reg, so tests
+// and embedding apps can scope them the same way MongoMetrics and Registry
+// do instead of binding to promauto's default registry. If db is non-nil, a
+// MongoChecker for it is registered automatically, matching the handler's
+// historical db-only behavior; additional checkers can be added via
+// Register.
+func NewHealthHandler(db *mongo.Database, reg prometheus.Registerer) *HealthHandler {
+	h := &HealthHandler{
+		results: make(map[string]checkResult),
+		stop:    make(chan struct{}),
+		healthStatus: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "health_status",
+				Help: "Health status of various components (1 = healthy, 0 = unhealthy)",
+			},
+			[]string{"component"},
+		),
+		checkDuration: promauto.With(reg).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "health_check_duration_seconds",
+				Help:    "Duration of dependency health checks in seconds.",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"component"},
+		),
+	}
+	if db != nil {
+		h.Register(NewMongoChecker(db))
 	}
+	return h
 }
 
-func (h *HealthHandler) HealthCheck(c *gin.Context) {
-	ctx := c.Request.Context()
-	
-	health := gin.H{
-		"status": "UP",
-		"components": gin.H{},
-	}
+// Register adds a Checker to be probed by Start's background loop.
+func (h *HealthHandler) Register(c Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers = append(h.checkers, c)
+}
+
+// Start runs an initial check synchronously, then probes all registered
+// checkers every interval in a background goroutine until Stop is called.
+func (h *HealthHandler) Start(interval time.Duration) {
+	h.runChecks(context.Background())
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+				h.runChecks(context.Background())
+			}
+		}
+	}()
+}
+
+// Stop ends the background check loop started by Start.
+func (h *HealthHandler) Stop() {
+	close(h.stop)
+}
+
+func (h *HealthHandler) runChecks(ctx context.Context) {
+	h.mu.RLock()
+	checkers := append([]Checker(nil), h.checkers...)
+	h.mu.RUnlock()
+
+	for _, c := range checkers {
+		start := time.Now()
+		err := c.Check(ctx)
+		latency := time.Since(start)
+		h.checkDuration.WithLabelValues(c.Name()).Observe(latency.Seconds())
 
-	// Check database connectivity
-	dbHealthy := h.checkDatabase(ctx)
-	health["components"].(gin.H)["db"] = map[string]interface{}{
-		"status": map[bool]string{true: "UP", false: "DOWN"}[dbHealthy],
+		h.mu.Lock()
+		res := h.results[c.Name()]
+		res.err = err
+		res.latency = latency
+		if err == nil {
+			res.lastOK = time.Now()
+			h.healthStatus.WithLabelValues(c.Name()).Set(1)
+		} else {
+			h.healthStatus.WithLabelValues(c.Name()).Set(0)
+		}
+		h.results[c.Name()] = res
+		h.mu.Unlock()
 	}
+}
+
+// Livez reports that the process is alive. It never depends on external
+// checks, so it stays cheap and fast for liveness probes.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "UP"})
+}
 
-	if !dbHealthy {
-		health["status"] = "DOWN"
-		healthStatus.WithLabelValues("db").Set(0)
-		c.JSON(http.StatusServiceUnavailable, health)
-		return
+// Readyz aggregates the last result of every critical Checker and returns
+// 503 if any of them is failing.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, checker := range h.checkers {
+		if !checker.Critical() {
+			continue
+		}
+		if res, ok := h.results[checker.Name()]; ok && res.err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":    "DOWN",
+				"component": checker.Name(),
+			})
+			return
+		}
 	}
 
-	healthStatus.WithLabelValues("db").Set(1)
-	c.JSON(http.StatusOK, health)
+	c.JSON(http.StatusOK, gin.H{"status": "UP"})
 }
 
-func (h *HealthHandler) checkDatabase(ctx context.Context) bool {
-	if h.db == nil {
-		return false
+// Healthz returns detailed, per-component status, latency, and last-success
+// timestamp for every registered Checker.
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	components := gin.H{}
+	overall := "UP"
+
+	for _, checker := range h.checkers {
+		res := h.results[checker.Name()]
+
+		status := "UP"
+		if res.err != nil {
+			status = "DOWN"
+			if checker.Critical() {
+				overall = "DOWN"
+			}
+		}
+
+		component := gin.H{
+			"status":    status,
+			"critical":  checker.Critical(),
+			"latencyMs": res.latency.Milliseconds(),
+		}
+		if !res.lastOK.IsZero() {
+			component["lastSuccess"] = res.lastOK
+		}
+		components[checker.Name()] = component
 	}
-	
-	start := time.Now()
-	err := h.db.Client().Ping(ctx, nil)
-	duration := time.Since(start)
-	
-	// Record ping metrics
-	metrics.RecordPing("go-webapi-db", h.db.Name(), duration)
-	
-	if err != nil {
-		metrics.RecordConnectionError("go-webapi-db", h.db.Name(), "ping_failed")
-		return false
+
+	code := http.StatusOK
+	if overall == "DOWN" {
+		code = http.StatusServiceUnavailable
 	}
-	
-	return true
+	c.JSON(code, gin.H{"status": overall, "components": components})
 }
 
+// HealthCheck serves the same report as Healthz, for routes still wired to
+// the single /health endpoint.
+func (h *HealthHandler) HealthCheck(c *gin.Context) { h.Healthz(c) }
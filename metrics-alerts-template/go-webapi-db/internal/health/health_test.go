@@ -2,106 +2,151 @@ package health
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func TestHealthHandler_HealthCheck(t *testing.T) {
+type stubChecker struct {
+	name     string
+	critical bool
+	err      error
+}
+
+func (s *stubChecker) Name() string                    { return s.name }
+func (s *stubChecker) Critical() bool                  { return s.critical }
+func (s *stubChecker) Check(ctx context.Context) error { return s.err }
+
+func TestHealthHandler_Livez(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewHealthHandler(nil, prometheus.NewRegistry())
+	r := gin.New()
+	r.GET("/livez", handler.Livez)
+
+	req, _ := http.NewRequest("GET", "/livez", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestHealthHandler_Readyz_FailsOnCriticalChecker(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	
-	// Create a test MongoDB client (will fail connection, but tests the handler)
-	client, _ := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
-	defer client.Disconnect(context.Background())
-	
-	db := client.Database("test")
-	handler := NewHealthHandler(db)
+
+	handler := NewHealthHandler(nil, prometheus.NewRegistry())
+	handler.Register(&stubChecker{name: "db", critical: true, err: errors.New("down")})
+	handler.runChecks(context.Background())
 
 	r := gin.New()
-	r.GET("/health", handler.HealthCheck)
+	r.GET("/readyz", handler.Readyz)
 
-	req, _ := http.NewRequest("GET", "/health", nil)
+	req, _ := http.NewRequest("GET", "/readyz", nil)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
-	if w.Code != http.StatusOK && w.Code != http.StatusServiceUnavailable {
-		t.Errorf("Expected status 200 or 503, got %d", w.Code)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+}
+
+func TestHealthHandler_Readyz_IgnoresNonCriticalChecker(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewHealthHandler(nil, prometheus.NewRegistry())
+	handler.Register(&stubChecker{name: "cache", critical: false, err: errors.New("down")})
+	handler.runChecks(context.Background())
+
+	r := gin.New()
+	r.GET("/readyz", handler.Readyz)
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestHealthHandler_Healthz_ReportsPerComponentStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewHealthHandler(nil, prometheus.NewRegistry())
+	handler.Register(&stubChecker{name: "db", critical: true, err: nil})
+	handler.runChecks(context.Background())
+
+	r := gin.New()
+	r.GET("/healthz", handler.Healthz)
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
 	}
 }
 
 func TestHealthHandler_HealthStatusMetric(t *testing.T) {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
-	
-	client, _ := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
-	defer client.Disconnect(context.Background())
-	
-	db := client.Database("test")
-	handler := NewHealthHandler(db)
 
-	// Check database (will likely fail, but sets metric)
-	handler.checkDatabase(context.Background())
+	handler := NewHealthHandler(nil, prometheus.DefaultRegisterer)
+	handler.Register(&stubChecker{name: "db", critical: true, err: nil})
+	handler.runChecks(context.Background())
 
 	reg := prometheus.DefaultRegisterer.(*prometheus.Registry)
-	metrics, err := reg.Gather()
+	metricFamilies, err := reg.Gather()
 	if err != nil {
-		t.Fatalf("Failed to gather metrics: %v", err)
+		t.Fatalf("failed to gather metrics: %v", err)
 	}
 
 	var found bool
-	for _, mf := range metrics {
+	for _, mf := range metricFamilies {
 		if mf.GetName() == "health_status" {
 			found = true
+			for _, metric := range mf.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "component" && label.GetValue() != "db" {
+						t.Errorf("expected component label 'db', got '%s'", label.GetValue())
+					}
+				}
+			}
 		}
 	}
 
 	if !found {
-		t.Error("Metric health_status not found")
+		t.Error("metric health_status not found")
 	}
 }
 
-func TestHealthHandler_HealthStatusLabels(t *testing.T) {
+func TestHealthHandler_HealthCheckDurationMetric(t *testing.T) {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
-	
-	client, _ := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
-	defer client.Disconnect(context.Background())
-	
-	db := client.Database("test")
-	handler := NewHealthHandler(db)
 
-	handler.checkDatabase(context.Background())
+	handler := NewHealthHandler(nil, prometheus.DefaultRegisterer)
+	handler.Register(&stubChecker{name: "db", critical: true, err: nil})
+	handler.runChecks(context.Background())
 
 	reg := prometheus.DefaultRegisterer.(*prometheus.Registry)
-	metrics, err := reg.Gather()
+	metricFamilies, err := reg.Gather()
 	if err != nil {
-		t.Fatalf("Failed to gather metrics: %v", err)
+		t.Fatalf("failed to gather metrics: %v", err)
 	}
 
 	var found bool
-	for _, mf := range metrics {
-		if mf.GetName() == "health_status" {
+	for _, mf := range metricFamilies {
+		if mf.GetName() == "health_check_duration_seconds" {
 			found = true
-			for _, metric := range mf.GetMetric() {
-				labels := metric.GetLabel()
-				var component string
-				for _, label := range labels {
-					if label.GetName() == "component" {
-						component = label.GetValue()
-					}
-				}
-				if component != "db" {
-					t.Errorf("Expected component label 'db', got '%s'", component)
-				}
-			}
 		}
 	}
 
 	if !found {
-		t.Error("Metric health_status with component label not found")
+		t.Error("metric health_check_duration_seconds not found")
 	}
 }
-
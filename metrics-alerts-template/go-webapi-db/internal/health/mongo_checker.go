@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"go-webapi-db/internal/metrics"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoChecker is a Checker that pings a MongoDB database.
+type MongoChecker struct {
+	db *mongo.Database
+}
+
+// NewMongoChecker creates a MongoChecker for db.
+func NewMongoChecker(db *mongo.Database) *MongoChecker {
+	return &MongoChecker{db: db}
+}
+
+// Name identifies this checker as "db".
+func (c *MongoChecker) Name() string { return "db" }
+
+// Critical reports true: a failing database is considered critical.
+func (c *MongoChecker) Critical() bool { return true }
+
+// Check pings the database and records ping/connection-error metrics.
+func (c *MongoChecker) Check(ctx context.Context) error {
+	start := time.Now()
+	err := c.db.Client().Ping(ctx, nil)
+	duration := time.Since(start)
+
+	metrics.RecordPing("go-webapi-db", c.db.Name(), duration)
+	if err != nil {
+		metrics.RecordConnectionError("go-webapi-db", c.db.Name(), "ping_failed")
+	}
+	return err
+}
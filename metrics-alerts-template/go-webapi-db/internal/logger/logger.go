@@ -0,0 +1,89 @@
+// Package logger provides structured JSON logging built on log/slog, with
+// automatic trace-ID enrichment sourced from internal/trace.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"go-webapi-db/internal/config"
+	"go-webapi-db/internal/trace"
+)
+
+type ctxKey string
+
+const loggerKey ctxKey = "logger"
+
+// Options configures the logger returned by New.
+type Options struct {
+	// Level sets the minimum level that will be logged. Defaults to slog.LevelInfo.
+	Level slog.Level
+	// AddSource includes the file:line of the log call when true.
+	AddSource bool
+}
+
+// New creates a slog.Logger that writes JSON records to w and automatically
+// attaches a "traceId" attribute to every record whose context carries one.
+func New(w io.Writer, opts Options) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:     opts.Level,
+		AddSource: opts.AddSource,
+	})
+	return slog.New(&traceHandler{next: handler})
+}
+
+// LevelFromEnv reads LOG_LEVEL (debug|info|warn|error) via config.GetString,
+// defaulting to info when unset or unrecognized.
+func LevelFromEnv() slog.Level {
+	switch config.GetString("LOG_LEVEL", "info") {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// NewContext returns a context carrying l, retrievable via FromContext.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, or
+// slog.Default() if none was stored.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// traceHandler wraps a slog.Handler and enriches every record with the
+// trace ID from the record's context, so call sites never have to attach it
+// themselves.
+type traceHandler struct {
+	next slog.Handler
+}
+
+func (h *traceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *traceHandler) Handle(ctx context.Context, record slog.Record) error {
+	if traceID := trace.FromContext(ctx); traceID != "" {
+		record.AddAttrs(slog.String("traceId", traceID))
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &traceHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	return &traceHandler{next: h.next.WithGroup(name)}
+}
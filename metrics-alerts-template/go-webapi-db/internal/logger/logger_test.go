@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-webapi-db/internal/trace"
+)
+
+func TestNew_EnrichesTraceID(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Level: slog.LevelInfo})
+
+	ctx := trace.NewContext(context.Background(), "trace-123")
+	l.InfoContext(ctx, "handler finished", "status", 200)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected JSON output, got error: %v (%s)", err, buf.String())
+	}
+
+	if entry["traceId"] != "trace-123" {
+		t.Errorf("expected traceId 'trace-123', got %v", entry["traceId"])
+	}
+	if entry["status"] != float64(200) {
+		t.Errorf("expected status 200, got %v", entry["status"])
+	}
+}
+
+func TestNew_NoTraceIDWhenAbsent(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Level: slog.LevelInfo})
+
+	l.Info("no trace here")
+
+	if strings.Contains(buf.String(), "traceId") {
+		t.Errorf("did not expect a traceId attribute, got: %s", buf.String())
+	}
+}
+
+func TestFromContext_DefaultsWhenUnset(t *testing.T) {
+	l := FromContext(context.Background())
+	if l == nil {
+		t.Fatal("expected a non-nil default logger")
+	}
+}
+
+func TestNewContext_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{})
+
+	ctx := NewContext(context.Background(), l)
+	if FromContext(ctx) != l {
+		t.Error("expected FromContext to return the logger stored by NewContext")
+	}
+}
+
+func TestDedupHandler_SuppressesRepeats(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.NewJSONHandler(&buf, nil)
+	l := slog.New(NewDedupHandler(base, 50*time.Millisecond))
+
+	l.Info("connection refused")
+	l.Info("connection refused")
+	l.Info("connection refused")
+
+	lines := strings.Count(strings.TrimSpace(buf.String()), "\n") + 1
+	if lines != 1 {
+		t.Errorf("expected 1 log line within the dedup window, got %d (%s)", lines, buf.String())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	l.Info("connection refused")
+
+	lines = strings.Count(strings.TrimSpace(buf.String()), "\n") + 1
+	if lines != 2 {
+		t.Errorf("expected a 2nd log line after the dedup window elapsed, got %d", lines)
+	}
+}
+
+// TestDedupHandler_WithAttrsSharesMutex guards against WithAttrs/WithGroup
+// handing out a fresh zero-value mutex over the still-shared seen map -
+// every derived handler (one per request, via slog.With) must serialize on
+// the same lock the original handler uses, or concurrent callers race on
+// the map. Run with -race to catch a regression.
+func TestDedupHandler_WithAttrsSharesMutex(t *testing.T) {
+	base := slog.NewJSONHandler(io.Discard, nil)
+	h := NewDedupHandler(base, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			derived := h.WithAttrs([]slog.Attr{slog.Int("worker", i)})
+			_ = derived.Handle(context.Background(), slog.Record{Message: "concurrent"})
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var userActiveLastHour = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "user_active_last_hour",
+	Help: "Number of distinct users that performed a user operation within the configured active-user window",
+})
+
+// ActiveUsersCollector tracks the most recent time each user performed an
+// operation (create/update/get/delete) and periodically reports how many
+// are still within window via the user_active_last_hour gauge, so
+// operators get a real "active users" SLI instead of only raw operation
+// counters. RecordSeen is called from UserService call sites; Start runs
+// the periodic eviction/publish sweep in the background, same shape as
+// SlowOpCollector/ErrorDedupHandler.
+type ActiveUsersCollector struct {
+	window time.Duration
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+}
+
+// NewActiveUsersCollector creates a collector that considers a user active
+// if RecordSeen was called for it within window (e.g. time.Hour).
+func NewActiveUsersCollector(window time.Duration) *ActiveUsersCollector {
+	return &ActiveUsersCollector{
+		window:   window,
+		stopCh:   make(chan struct{}),
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+// WithLogger attaches logger. Returns c for chaining.
+func (c *ActiveUsersCollector) WithLogger(logger *slog.Logger) *ActiveUsersCollector {
+	c.logger = logger
+	return c
+}
+
+// RecordSeen marks userID as having performed an operation just now. A
+// blank userID (e.g. an operation that failed before a user was resolved)
+// is ignored.
+func (c *ActiveUsersCollector) RecordSeen(userID string) {
+	if userID == "" {
+		return
+	}
+	c.mu.Lock()
+	c.lastSeen[userID] = time.Now()
+	c.mu.Unlock()
+}
+
+// Start begins periodically evicting entries older than window and
+// publishing the remaining count to user_active_last_hour.
+func (c *ActiveUsersCollector) Start(interval time.Duration) {
+	c.wg.Add(1)
+	go c.collectLoop(interval)
+}
+
+// Stop stops the periodic sweep.
+func (c *ActiveUsersCollector) Stop() {
+	close(c.stopCh)
+	c.wg.Wait()
+}
+
+func (c *ActiveUsersCollector) collectLoop(interval time.Duration) {
+	defer c.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	c.collect()
+	for {
+		select {
+		case <-ticker.C:
+			c.collect()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+func (c *ActiveUsersCollector) collect() {
+	cutoff := time.Now().Add(-c.window)
+
+	c.mu.Lock()
+	active := 0
+	for userID, seenAt := range c.lastSeen {
+		if seenAt.Before(cutoff) {
+			delete(c.lastSeen, userID)
+			continue
+		}
+		active++
+	}
+	c.mu.Unlock()
+
+	userActiveLastHour.Set(float64(active))
+	if c.logger != nil {
+		c.logger.Debug("active users updated", "count", active, "window", c.window)
+	}
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func gatherUserActiveLastHour(t *testing.T) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() == "user_active_last_hour" {
+			return mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatal("metric user_active_last_hour not found")
+	return 0
+}
+
+func TestActiveUsersCollector_CountsDistinctUsersWithinWindow(t *testing.T) {
+	c := NewActiveUsersCollector(time.Hour)
+
+	c.RecordSeen("user-1")
+	c.RecordSeen("user-2")
+	c.RecordSeen("user-1") // repeat seen shouldn't double-count
+
+	c.collect()
+
+	if got := gatherUserActiveLastHour(t); got != 2 {
+		t.Errorf("expected 2 active users, got %v", got)
+	}
+}
+
+func TestActiveUsersCollector_EvictsEntriesOlderThanWindow(t *testing.T) {
+	c := NewActiveUsersCollector(time.Hour)
+
+	c.mu.Lock()
+	c.lastSeen["stale-user"] = time.Now().Add(-2 * time.Hour)
+	c.mu.Unlock()
+	c.RecordSeen("fresh-user")
+
+	c.collect()
+
+	if got := gatherUserActiveLastHour(t); got != 1 {
+		t.Errorf("expected 1 active user after eviction, got %v", got)
+	}
+
+	c.mu.Lock()
+	_, staleStillTracked := c.lastSeen["stale-user"]
+	c.mu.Unlock()
+	if staleStillTracked {
+		t.Error("expected stale-user to be evicted from lastSeen")
+	}
+}
+
+func TestActiveUsersCollector_IgnoresBlankUserID(t *testing.T) {
+	c := NewActiveUsersCollector(time.Hour)
+
+	c.RecordSeen("")
+
+	c.mu.Lock()
+	n := len(c.lastSeen)
+	c.mu.Unlock()
+	if n != 0 {
+		t.Errorf("expected blank userID to be ignored, got %d tracked entries", n)
+	}
+}
@@ -0,0 +1,198 @@
+// Package dashboard introspects an application's registered Prometheus
+// metric families and generates a ready-to-use Grafana dashboard and
+// Prometheus recording/alerting rules covering the RED signals exposed by
+// http_server_requests_* and the USE signals exposed by the mongodb_*
+// connection pool gauges, so operators get an operable monitoring package
+// instead of just raw /metrics.
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	httpRequestsTotalMetric  = "http_server_requests_total"
+	httpRequestSecondsMetric = "http_server_requests_seconds"
+	httpErrorsTotalMetric    = "http_server_errors_total"
+	mongoConnectionsMax      = "mongodb_connections_max"
+	mongoConnectionsMin      = "mongodb_connections_min"
+)
+
+// Generate introspects gatherer's currently registered metric families and
+// returns a Grafana dashboard (dashboardJSON) and Prometheus recording/
+// alerting rules (rulesYAML) covering whichever of the RED/USE metric
+// families above are actually present.
+func Generate(gatherer prometheus.Gatherer) (dashboardJSON []byte, rulesYAML []byte, err error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, nil, fmt.Errorf("gather metric families: %w", err)
+	}
+
+	present := make(map[string]bool, len(families))
+	for _, mf := range families {
+		present[mf.GetName()] = true
+	}
+	uris := uriLabelValues(families)
+
+	dashboardJSON, err = json.MarshalIndent(buildDashboard(present, uris), "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal dashboard: %w", err)
+	}
+
+	return dashboardJSON, []byte(buildRulesYAML(present, uris)), nil
+}
+
+// uriLabelValues collects the distinct "uri" label values seen on
+// http_server_requests_total, so the dashboard/rules can break latency and
+// error rate panels out per endpoint instead of one aggregate series.
+func uriLabelValues(families []*dto.MetricFamily) []string {
+	seen := make(map[string]bool)
+	for _, mf := range families {
+		if mf.GetName() != httpRequestsTotalMetric {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "uri" {
+					seen[label.GetValue()] = true
+				}
+			}
+		}
+	}
+
+	uris := make([]string, 0, len(seen))
+	for uri := range seen {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+func buildDashboard(present map[string]bool, uris []string) map[string]interface{} {
+	var panels []map[string]interface{}
+	id, y := 1, 0
+
+	if present[httpRequestSecondsMetric] {
+		for _, uri := range uris {
+			panels = append(panels, latencyPanel(id, y, uri))
+			id++
+			y += 8
+		}
+	}
+	if present[httpErrorsTotalMetric] && present[httpRequestsTotalMetric] {
+		panels = append(panels, errorRatePanel(id, y))
+		id++
+		y += 8
+	}
+	if present[mongoConnectionsMax] && present[mongoConnectionsMin] {
+		panels = append(panels, mongoSaturationPanel(id, y))
+		id++
+		y += 8
+	}
+
+	return map[string]interface{}{
+		"title":         "go-webapi-db: RED/USE overview",
+		"schemaVersion": 36,
+		"panels":        panels,
+	}
+}
+
+func latencyPanel(id, y int, uri string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      id,
+		"title":   fmt.Sprintf("Latency p50/p95/p99 - %s", uri),
+		"type":    "timeseries",
+		"gridPos": map[string]interface{}{"h": 8, "w": 24, "x": 0, "y": y},
+		"targets": []map[string]interface{}{
+			{"legendFormat": "p50", "expr": quantileExpr("0.50", uri)},
+			{"legendFormat": "p95", "expr": quantileExpr("0.95", uri)},
+			{"legendFormat": "p99", "expr": quantileExpr("0.99", uri)},
+		},
+	}
+}
+
+func quantileExpr(quantile, uri string) string {
+	return fmt.Sprintf(
+		`histogram_quantile(%s, sum(rate(http_server_requests_seconds_bucket{uri=%q}[5m])) by (le))`,
+		quantile, uri,
+	)
+}
+
+func errorRatePanel(id, y int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      id,
+		"title":   "HTTP error rate",
+		"type":    "timeseries",
+		"gridPos": map[string]interface{}{"h": 8, "w": 24, "x": 0, "y": y},
+		"targets": []map[string]interface{}{
+			{"legendFormat": "error rate", "expr": `sum(rate(http_server_errors_total[5m])) / sum(rate(http_server_requests_total[5m]))`},
+		},
+	}
+}
+
+func mongoSaturationPanel(id, y int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      id,
+		"title":   "MongoDB pool saturation",
+		"type":    "timeseries",
+		"gridPos": map[string]interface{}{"h": 8, "w": 24, "x": 0, "y": y},
+		"targets": []map[string]interface{}{
+			{"legendFormat": "headroom", "expr": `mongodb_connections_max - mongodb_connections_min`},
+		},
+	}
+}
+
+// buildRulesYAML hand-formats the rules file rather than pulling in a YAML
+// library: the structure is fixed and shallow enough that string templating
+// is simpler than adding a new dependency this source tree has no manifest
+// to pin.
+func buildRulesYAML(present map[string]bool, uris []string) string {
+	hasRED := present[httpRequestSecondsMetric] || (present[httpErrorsTotalMetric] && present[httpRequestsTotalMetric])
+	hasUSE := present[mongoConnectionsMax] && present[mongoConnectionsMin]
+	if !hasRED && !hasUSE {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("groups:\n")
+
+	if hasRED {
+		b.WriteString("  - name: go-webapi-db.red\n")
+		b.WriteString("    rules:\n")
+
+		if present[httpRequestSecondsMetric] {
+			for _, uri := range uris {
+				for _, q := range []struct{ name, ratio string }{{"p50", "0.50"}, {"p95", "0.95"}, {"p99", "0.99"}} {
+					fmt.Fprintf(&b, "      - record: http_request_duration_%s:rate5m\n", q.name)
+					fmt.Fprintf(&b, "        expr: %s\n", quantileExpr(q.ratio, uri))
+					fmt.Fprintf(&b, "        labels:\n          uri: %q\n          application: go-webapi-db\n", uri)
+				}
+			}
+		}
+
+		if present[httpErrorsTotalMetric] && present[httpRequestsTotalMetric] {
+			b.WriteString("      - record: http_error_rate:rate5m\n")
+			b.WriteString("        expr: sum(rate(http_server_errors_total[5m])) / sum(rate(http_server_requests_total[5m]))\n")
+			b.WriteString("      - alert: HighHTTPErrorRate\n")
+			b.WriteString("        expr: http_error_rate:rate5m > 0.05\n")
+			b.WriteString("        for: 5m\n")
+			b.WriteString("        labels:\n          severity: warning\n")
+			b.WriteString("        annotations:\n          summary: \"HTTP error rate above 5% for 5m\"\n")
+		}
+	}
+
+	if hasUSE {
+		b.WriteString("  - name: go-webapi-db.use\n")
+		b.WriteString("    rules:\n")
+		b.WriteString("      - record: mongodb_pool_saturation:headroom\n")
+		b.WriteString("        expr: mongodb_connections_max - mongodb_connections_min\n")
+	}
+
+	return b.String()
+}
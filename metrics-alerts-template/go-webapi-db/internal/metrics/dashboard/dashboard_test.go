@@ -0,0 +1,60 @@
+package dashboard
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestGenerate_IncludesREDAndUSEWhenMetricsPresent(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	requestsTotal := prometheus.NewCounterVec(prometheus.CounterOpts{Name: httpRequestsTotalMetric}, []string{"uri", "method", "status"})
+	requestSeconds := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: httpRequestSecondsMetric}, []string{"uri", "method", "status"})
+	errorsTotal := prometheus.NewCounterVec(prometheus.CounterOpts{Name: httpErrorsTotalMetric}, []string{"uri", "method", "status"})
+	connMax := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: mongoConnectionsMax}, []string{"application", "database"})
+	connMin := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: mongoConnectionsMin}, []string{"application", "database"})
+	reg.MustRegister(requestsTotal, requestSeconds, errorsTotal, connMax, connMin)
+
+	requestsTotal.WithLabelValues("/api/users/{id}", "GET", "200").Inc()
+	requestSeconds.WithLabelValues("/api/users/{id}", "GET", "200").Observe(0.05)
+	connMax.WithLabelValues("go-webapi-db", "go_webapi_db").Set(10)
+	connMin.WithLabelValues("go-webapi-db", "go_webapi_db").Set(5)
+
+	dashboardJSON, rulesYAML, err := Generate(reg)
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	if !strings.Contains(string(dashboardJSON), "/api/users/{id}") {
+		t.Errorf("expected dashboard JSON to reference discovered uri label, got: %s", dashboardJSON)
+	}
+	if !strings.Contains(string(dashboardJSON), "MongoDB pool saturation") {
+		t.Errorf("expected dashboard JSON to include a USE panel, got: %s", dashboardJSON)
+	}
+
+	rules := string(rulesYAML)
+	if !strings.Contains(rules, "histogram_quantile(0.95") {
+		t.Errorf("expected rules YAML to include a p95 recording rule, got: %s", rules)
+	}
+	if !strings.Contains(rules, "mongodb_pool_saturation:headroom") {
+		t.Errorf("expected rules YAML to include the USE saturation rule, got: %s", rules)
+	}
+}
+
+func TestGenerate_OmitsUSEGroupWhenMongoMetricsAbsent(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	requestsTotal := prometheus.NewCounterVec(prometheus.CounterOpts{Name: httpRequestsTotalMetric}, []string{"uri", "method", "status"})
+	reg.MustRegister(requestsTotal)
+	requestsTotal.WithLabelValues("/health", "GET", "200").Inc()
+
+	_, rulesYAML, err := Generate(reg)
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	if strings.Contains(string(rulesYAML), "go-webapi-db.use") {
+		t.Errorf("expected no USE rule group without mongodb connection metrics, got: %s", rulesYAML)
+	}
+}
@@ -0,0 +1,29 @@
+package metrics
+
+// Exporter is a backend-agnostic sink for the three metric kinds
+// MetricsService and MetricsMiddleware record, so an application can target
+// Prometheus, DogStatsD/Datadog, an OTLP collector, or several at once
+// instead of being hard-wired to prometheus.DefaultRegisterer. labels are
+// passed as alternating key/value pairs (Prometheus calls these labels,
+// DogStatsD and OTLP call them tags/attributes) so a single call site
+// serializes cleanly to any backend. Implementations must be safe for
+// concurrent use.
+type Exporter interface {
+	// IncrCounter adds value (normally 1) to the named monotonic counter.
+	IncrCounter(name string, value float64, labels ...string)
+	// ObserveHistogram records one observation of value for the named
+	// histogram/distribution.
+	ObserveHistogram(name string, value float64, labels ...string)
+	// SetGauge sets the named gauge to value.
+	SetGauge(name string, value float64, labels ...string)
+}
+
+// ExemplarObserver is an optional Exporter capability: backends that can
+// attach an exemplar (e.g. a trace/span ID) to a single histogram
+// observation implement it too. Callers type-assert for it and fall back
+// to plain ObserveHistogram when the active backend doesn't support
+// exemplars - currently only PrometheusExporter does; OpenMetrics is a
+// Prometheus-specific exposition format extension.
+type ExemplarObserver interface {
+	ObserveHistogramWithExemplar(name string, value float64, exemplarLabels map[string]string, labels ...string)
+}
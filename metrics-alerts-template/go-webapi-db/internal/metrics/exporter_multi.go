@@ -0,0 +1,32 @@
+package metrics
+
+// MultiExporter fans every call out to all of its backends, for
+// METRICS_EXPORTER=multi deployments that want to dual-write (e.g. while
+// migrating from Prometheus to OTLP).
+type MultiExporter struct {
+	exporters []Exporter
+}
+
+// NewMultiExporter returns an Exporter that forwards every call to each of
+// exporters in order.
+func NewMultiExporter(exporters ...Exporter) *MultiExporter {
+	return &MultiExporter{exporters: exporters}
+}
+
+func (e *MultiExporter) IncrCounter(name string, value float64, labels ...string) {
+	for _, exporter := range e.exporters {
+		exporter.IncrCounter(name, value, labels...)
+	}
+}
+
+func (e *MultiExporter) ObserveHistogram(name string, value float64, labels ...string) {
+	for _, exporter := range e.exporters {
+		exporter.ObserveHistogram(name, value, labels...)
+	}
+}
+
+func (e *MultiExporter) SetGauge(name string, value float64, labels ...string) {
+	for _, exporter := range e.exporters {
+		exporter.SetGauge(name, value, labels...)
+	}
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import "testing"
+
+type recordingExporter struct {
+	counters   []string
+	histograms []string
+	gauges     []string
+}
+
+func (e *recordingExporter) IncrCounter(name string, value float64, labels ...string) {
+	e.counters = append(e.counters, name)
+}
+
+func (e *recordingExporter) ObserveHistogram(name string, value float64, labels ...string) {
+	e.histograms = append(e.histograms, name)
+}
+
+func (e *recordingExporter) SetGauge(name string, value float64, labels ...string) {
+	e.gauges = append(e.gauges, name)
+}
+
+func TestMultiExporter_FansOutToAllBackends(t *testing.T) {
+	a := &recordingExporter{}
+	b := &recordingExporter{}
+	e := NewMultiExporter(a, b)
+
+	e.IncrCounter("requests_total", 1, "route", "/a")
+	e.ObserveHistogram("request_duration_seconds", 0.1, "route", "/a")
+	e.SetGauge("pool_size", 5, "database", "orders")
+
+	for _, recorder := range []*recordingExporter{a, b} {
+		if len(recorder.counters) != 1 || recorder.counters[0] != "requests_total" {
+			t.Errorf("expected requests_total recorded once, got %v", recorder.counters)
+		}
+		if len(recorder.histograms) != 1 || recorder.histograms[0] != "request_duration_seconds" {
+			t.Errorf("expected request_duration_seconds recorded once, got %v", recorder.histograms)
+		}
+		if len(recorder.gauges) != 1 || recorder.gauges[0] != "pool_size" {
+			t.Errorf("expected pool_size recorded once, got %v", recorder.gauges)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// OTLPExporter pushes counters and histograms to an OpenTelemetry collector
+// endpoint on a periodic interval via OTLP/HTTP, for users who run an
+// OTLP-first observability stack instead of scraping Prometheus. Gauges
+// have no first-class "set to value" OTLP instrument, so SetGauge is
+// modeled as an UpDownCounter delta from the last recorded value, which
+// sums to the same cumulative value a true gauge would report.
+type OTLPExporter struct {
+	provider *sdkmetric.MeterProvider
+	meter    metric.Meter
+
+	mu          sync.Mutex
+	counters    map[string]metric.Float64Counter
+	histograms  map[string]metric.Float64Histogram
+	gauges      map[string]metric.Float64UpDownCounter
+	gaugeValues map[string]float64
+}
+
+// NewOTLPExporter dials endpoint and starts a periodic reader that pushes
+// every pushInterval. Call Shutdown to flush and stop the push loop.
+func NewOTLPExporter(ctx context.Context, appName, endpoint string, pushInterval time.Duration) (*OTLPExporter, error) {
+	exporter, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpoint(endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName(appName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(pushInterval))),
+	)
+
+	return &OTLPExporter{
+		provider:    provider,
+		meter:       provider.Meter(appName),
+		counters:    make(map[string]metric.Float64Counter),
+		histograms:  make(map[string]metric.Float64Histogram),
+		gauges:      make(map[string]metric.Float64UpDownCounter),
+		gaugeValues: make(map[string]float64),
+	}, nil
+}
+
+// Shutdown flushes any pending data and stops the periodic push loop.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	return e.provider.Shutdown(ctx)
+}
+
+func attrsOf(labels []string) []attribute.KeyValue {
+	names, values := labelPairs(labels)
+	attrs := make([]attribute.KeyValue, len(names))
+	for i, name := range names {
+		attrs[i] = attribute.String(name, values[i])
+	}
+	return attrs
+}
+
+func (e *OTLPExporter) IncrCounter(name string, value float64, labels ...string) {
+	e.mu.Lock()
+	c, ok := e.counters[name]
+	if !ok {
+		var err error
+		c, err = e.meter.Float64Counter(name)
+		if err != nil {
+			e.mu.Unlock()
+			return
+		}
+		e.counters[name] = c
+	}
+	e.mu.Unlock()
+
+	c.Add(context.Background(), value, metric.WithAttributes(attrsOf(labels)...))
+}
+
+func (e *OTLPExporter) ObserveHistogram(name string, value float64, labels ...string) {
+	e.mu.Lock()
+	h, ok := e.histograms[name]
+	if !ok {
+		var err error
+		h, err = e.meter.Float64Histogram(name)
+		if err != nil {
+			e.mu.Unlock()
+			return
+		}
+		e.histograms[name] = h
+	}
+	e.mu.Unlock()
+
+	h.Record(context.Background(), value, metric.WithAttributes(attrsOf(labels)...))
+}
+
+func (e *OTLPExporter) SetGauge(name string, value float64, labels ...string) {
+	_, valueStrs := labelPairs(labels)
+	gaugeKey := name + "|" + strconv.Itoa(len(valueStrs))
+	for _, v := range valueStrs {
+		gaugeKey += "|" + v
+	}
+
+	e.mu.Lock()
+	g, ok := e.gauges[name]
+	if !ok {
+		var err error
+		g, err = e.meter.Float64UpDownCounter(name)
+		if err != nil {
+			e.mu.Unlock()
+			return
+		}
+		e.gauges[name] = g
+	}
+	last := e.gaugeValues[gaugeKey]
+	e.gaugeValues[gaugeKey] = value
+	e.mu.Unlock()
+
+	g.Add(context.Background(), value-last, metric.WithAttributes(attrsOf(labels)...))
+}
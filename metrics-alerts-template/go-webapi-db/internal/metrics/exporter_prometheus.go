@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusExporter adapts a prometheus.Registerer to the Exporter
+// interface. Unlike MongoMetrics/Registry (which declare their Vecs up
+// front with fixed label names), PrometheusExporter's callers pass labels
+// as name/value pairs per call, so it lazily creates a Vec for each
+// distinct metric name the first time that name is used and reuses it
+// afterward. The label *names* for a given metric are inferred from the
+// first call and must stay consistent across later calls to the same name,
+// same as any other Prometheus Vec.
+type PrometheusExporter struct {
+	reg prometheus.Registerer
+
+	mu         sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+	gauges     map[string]*prometheus.GaugeVec
+}
+
+// NewPrometheusExporter builds a PrometheusExporter registering its
+// lazily-created Vecs against reg.
+func NewPrometheusExporter(reg prometheus.Registerer) *PrometheusExporter {
+	return &PrometheusExporter{
+		reg:        reg,
+		counters:   make(map[string]*prometheus.CounterVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+		gauges:     make(map[string]*prometheus.GaugeVec),
+	}
+}
+
+// labelPairs splits alternating key/value labels into parallel name/value
+// slices, the shape prometheus.NewXVec/WithLabelValues expect.
+func labelPairs(labels []string) (names, values []string) {
+	for i := 0; i+1 < len(labels); i += 2 {
+		names = append(names, labels[i])
+		values = append(values, labels[i+1])
+	}
+	return names, values
+}
+
+func (e *PrometheusExporter) IncrCounter(name string, value float64, labels ...string) {
+	names, values := labelPairs(labels)
+
+	e.mu.Lock()
+	c, ok := e.counters[name]
+	if !ok {
+		c = prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: name}, names)
+		e.reg.MustRegister(c)
+		e.counters[name] = c
+	}
+	e.mu.Unlock()
+
+	c.WithLabelValues(values...).Add(value)
+}
+
+func (e *PrometheusExporter) ObserveHistogram(name string, value float64, labels ...string) {
+	names, values := labelPairs(labels)
+
+	e.mu.Lock()
+	h, ok := e.histograms[name]
+	if !ok {
+		h = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: name, Buckets: prometheus.DefBuckets}, names)
+		e.reg.MustRegister(h)
+		e.histograms[name] = h
+	}
+	e.mu.Unlock()
+
+	h.WithLabelValues(values...).Observe(value)
+}
+
+// ObserveHistogramWithExemplar is like ObserveHistogram but attaches
+// exemplarLabels (e.g. trace_id/span_id) to the observation, so Grafana/
+// Prometheus can jump from a latency spike straight to the trace that
+// produced it. Implements metrics.ExemplarObserver.
+func (e *PrometheusExporter) ObserveHistogramWithExemplar(name string, value float64, exemplarLabels map[string]string, labels ...string) {
+	names, values := labelPairs(labels)
+
+	e.mu.Lock()
+	h, ok := e.histograms[name]
+	if !ok {
+		h = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: name, Buckets: prometheus.DefBuckets}, names)
+		e.reg.MustRegister(h)
+		e.histograms[name] = h
+	}
+	e.mu.Unlock()
+
+	observer := h.WithLabelValues(values...)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+		exemplarObserver.ObserveWithExemplar(value, exemplarLabels)
+		return
+	}
+	observer.Observe(value)
+}
+
+func (e *PrometheusExporter) SetGauge(name string, value float64, labels ...string) {
+	names, values := labelPairs(labels)
+
+	e.mu.Lock()
+	g, ok := e.gauges[name]
+	if !ok {
+		g = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: name}, names)
+		e.reg.MustRegister(g)
+		e.gauges[name] = g
+	}
+	e.mu.Unlock()
+
+	g.WithLabelValues(values...).Set(value)
+}
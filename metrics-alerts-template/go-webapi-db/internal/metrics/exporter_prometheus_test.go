@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func gatherMetricFamily(t *testing.T, reg *prometheus.Registry, name string) *prometheus.MetricFamily {
+	t.Helper()
+
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error: %v", err)
+	}
+
+	for _, mf := range metricFamilies {
+		if mf.GetName() == name {
+			return mf
+		}
+	}
+	return nil
+}
+
+func TestPrometheusExporter_IncrCounterAccumulatesByLabels(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	e := NewPrometheusExporter(reg)
+
+	e.IncrCounter("requests_total", 1, "route", "/a")
+	e.IncrCounter("requests_total", 2, "route", "/a")
+	e.IncrCounter("requests_total", 1, "route", "/b")
+
+	mf := gatherMetricFamily(t, reg, "requests_total")
+	if mf == nil {
+		t.Fatal("expected requests_total to be registered")
+	}
+
+	var gotA, gotB float64
+	for _, m := range mf.GetMetric() {
+		for _, label := range m.GetLabel() {
+			if label.GetName() == "route" && label.GetValue() == "/a" {
+				gotA = m.GetCounter().GetValue()
+			}
+			if label.GetName() == "route" && label.GetValue() == "/b" {
+				gotB = m.GetCounter().GetValue()
+			}
+		}
+	}
+	if gotA != 3 {
+		t.Errorf("expected route=/a counter of 3, got %v", gotA)
+	}
+	if gotB != 1 {
+		t.Errorf("expected route=/b counter of 1, got %v", gotB)
+	}
+}
+
+func TestPrometheusExporter_ObserveHistogramRecordsCount(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	e := NewPrometheusExporter(reg)
+
+	e.ObserveHistogram("request_duration_seconds", 0.1, "route", "/a")
+	e.ObserveHistogram("request_duration_seconds", 0.2, "route", "/a")
+
+	mf := gatherMetricFamily(t, reg, "request_duration_seconds")
+	if mf == nil {
+		t.Fatal("expected request_duration_seconds to be registered")
+	}
+	if got := mf.GetMetric()[0].GetHistogram().GetSampleCount(); got != 2 {
+		t.Errorf("expected sample count 2, got %d", got)
+	}
+}
+
+func TestPrometheusExporter_ObserveHistogramWithExemplarAttachesExemplar(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	e := NewPrometheusExporter(reg)
+
+	e.ObserveHistogramWithExemplar("request_duration_seconds", 0.1, map[string]string{"trace_id": "abc123"}, "route", "/a")
+
+	mf := gatherMetricFamily(t, reg, "request_duration_seconds")
+	if mf == nil {
+		t.Fatal("expected request_duration_seconds to be registered")
+	}
+
+	var found bool
+	for _, bucket := range mf.GetMetric()[0].GetHistogram().GetBucket() {
+		if exemplar := bucket.GetExemplar(); exemplar != nil {
+			for _, label := range exemplar.GetLabel() {
+				if label.GetName() == "trace_id" && label.GetValue() == "abc123" {
+					found = true
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("expected a bucket exemplar with trace_id=abc123")
+	}
+}
+
+func TestPrometheusExporter_SetGaugeOverwrites(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	e := NewPrometheusExporter(reg)
+
+	e.SetGauge("pool_size", 5, "database", "orders")
+	e.SetGauge("pool_size", 9, "database", "orders")
+
+	mf := gatherMetricFamily(t, reg, "pool_size")
+	if mf == nil {
+		t.Fatal("expected pool_size to be registered")
+	}
+	if got := mf.GetMetric()[0].GetGauge().GetValue(); got != 9 {
+		t.Errorf("expected gauge value 9, got %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"context"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -10,6 +11,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// Deprecated: these package-level vars are bound to promauto's default
+// registry, so tests can't isolate them and embedding apps can't scope
+// them to a custom registry. Use NewMongoMetrics instead.
 var (
 	// Connection pool metrics
 	mongodbConnectionsActive = promauto.NewGaugeVec(
@@ -123,6 +127,8 @@ type MongoDBMetricsCollector struct {
 	appName  string
 	stopCh   chan struct{}
 	wg       sync.WaitGroup
+
+	logger *slog.Logger
 }
 
 // NewMongoDBMetricsCollector creates a new MongoDB metrics collector
@@ -135,8 +141,21 @@ func NewMongoDBMetricsCollector(client *mongo.Client, database, appName string)
 	}
 }
 
+// WithLogger attaches logger so the collector's lifecycle (and, in future,
+// any reconciliation collectConnectionPoolStats performs) is also emitted
+// as structured log records alongside its Prometheus series. Returns c for
+// chaining.
+func (c *MongoDBMetricsCollector) WithLogger(logger *slog.Logger) *MongoDBMetricsCollector {
+	c.logger = logger
+	return c
+}
+
 // Start begins collecting connection pool metrics periodically
 func (c *MongoDBMetricsCollector) Start(interval time.Duration) {
+	if c.logger != nil {
+		c.logger.Info("mongodb metrics collector started",
+			"application", c.appName, "database", c.database, "interval_ms", interval.Milliseconds())
+	}
 	c.wg.Add(1)
 	go c.collectLoop(interval)
 }
@@ -145,6 +164,9 @@ func (c *MongoDBMetricsCollector) Start(interval time.Duration) {
 func (c *MongoDBMetricsCollector) Stop() {
 	close(c.stopCh)
 	c.wg.Wait()
+	if c.logger != nil {
+		c.logger.Info("mongodb metrics collector stopped", "application", c.appName, "database", c.database)
+	}
 }
 
 func (c *MongoDBMetricsCollector) collectLoop(interval time.Duration) {
@@ -165,37 +187,49 @@ func (c *MongoDBMetricsCollector) collectLoop(interval time.Duration) {
 	}
 }
 
-func (c *MongoDBMetricsCollector) collectConnectionPoolStats() {
-	if c.client == nil {
-		return
-	}
+// collectConnectionPoolStats is a no-op now that mongodb_connections_active,
+// mongodb_connections_idle, and mongodb_connections_total are updated in
+// real time by the PoolMonitor installed via NewMonitoredClientOptions. The
+// periodic loop is kept in place as the spot for any future authoritative
+// reconciliation (e.g. against a serverStatus command).
+func (c *MongoDBMetricsCollector) collectConnectionPoolStats() {}
 
-	labels := []string{c.appName, c.database}
-	
-	// Note: MongoDB Go driver doesn't expose detailed pool stats directly via public API
-	// Connection pool stats are tracked internally. We set the configuration values
-	// which are set via SetConnectionPoolConfig() when the client is created.
-	// For production, you might want to use MongoDB server status commands or
-	// implement connection wrapping to track actual usage.
-	
-	// The max and min values are set via SetConnectionPoolConfig() in main.go
-	// Here we just ensure the metrics exist (they're already set)
-	
-	// For active/idle/total, we approximate based on typical usage
-	// In a production system, you'd want to track these more accurately
-	// by wrapping connection acquisition or using MongoDB server status
-	mongodbConnectionsActive.WithLabelValues(labels...).Set(0)
-	mongodbConnectionsIdle.WithLabelValues(labels...).Set(5)
-	mongodbConnectionsTotal.WithLabelValues(labels...).Set(5)
+// activeExporter, when set via SetActiveExporter, receives a copy of every
+// RecordOperation call in addition to the package-level Prometheus vecs
+// above, so deployments that select a non-Prometheus METRICS_EXPORTER
+// still see MongoDB operation metrics instead of silently losing them to
+// the deprecated, Prometheus-only code path.
+var (
+	activeExporterMu sync.RWMutex
+	activeExporter   Exporter
+)
+
+// SetActiveExporter sets the Exporter RecordOperation also reports through.
+// Pass nil to report through the package-level Prometheus vecs only.
+func SetActiveExporter(e Exporter) {
+	activeExporterMu.Lock()
+	defer activeExporterMu.Unlock()
+	activeExporter = e
 }
 
-// RecordOperation records a MongoDB operation
+// RecordOperation records a MongoDB operation.
+//
+// Deprecated: use (*MongoMetrics).RecordOperation so callers can scope
+// metrics to their own Registerer.
 func RecordOperation(appName, database, operation, collection string, duration time.Duration, err error) {
 	labels := []string{appName, database, operation, collection}
-	
+
 	mongodbOperationsTotal.WithLabelValues(labels...).Inc()
 	mongodbOperationDuration.WithLabelValues(labels...).Observe(duration.Seconds())
-	
+
+	activeExporterMu.RLock()
+	exporter := activeExporter
+	activeExporterMu.RUnlock()
+	if exporter != nil {
+		exporter.IncrCounter("mongodb_operations_total", 1, "application", appName, "database", database, "operation", operation, "collection", collection)
+		exporter.ObserveHistogram("mongodb_operation_duration_seconds", duration.Seconds(), "application", appName, "database", database, "operation", operation, "collection", collection)
+	}
+
 	if err != nil {
 		errorType := "unknown"
 		if err == mongo.ErrNoDocuments {
@@ -205,13 +239,19 @@ func RecordOperation(appName, database, operation, collection string, duration t
 		} else if err == context.Canceled {
 			errorType = "cancelled"
 		}
-		
+
 		errorLabels := append(labels, errorType)
 		mongodbOperationErrors.WithLabelValues(errorLabels...).Inc()
+
+		if exporter != nil {
+			exporter.IncrCounter("mongodb_operation_errors_total", 1, "application", appName, "database", database, "operation", operation, "collection", collection, "error_type", errorType)
+		}
 	}
 }
 
-// RecordConnectionAcquisition records connection acquisition time
+// RecordConnectionAcquisition records connection acquisition time.
+//
+// Deprecated: use (*MongoMetrics).RecordConnectionAcquisition.
 func RecordConnectionAcquisition(appName, database string, duration time.Duration, timeout bool) {
 	labels := []string{appName, database}
 	
@@ -222,19 +262,25 @@ func RecordConnectionAcquisition(appName, database string, duration time.Duratio
 	}
 }
 
-// RecordConnectionError records a connection error
+// RecordConnectionError records a connection error.
+//
+// Deprecated: use (*MongoMetrics).RecordConnectionError.
 func RecordConnectionError(appName, database, errorType string) {
 	labels := []string{appName, database, errorType}
 	mongodbConnectionErrors.WithLabelValues(labels...).Inc()
 }
 
-// RecordPing records a MongoDB ping operation
+// RecordPing records a MongoDB ping operation.
+//
+// Deprecated: use (*MongoMetrics).RecordPing.
 func RecordPing(appName, database string, duration time.Duration) {
 	labels := []string{appName, database}
 	mongodbPingDuration.WithLabelValues(labels...).Observe(duration.Seconds())
 }
 
-// SetConnectionPoolConfig sets the connection pool configuration metrics
+// SetConnectionPoolConfig sets the connection pool configuration metrics.
+//
+// Deprecated: use (*MongoMetrics).SetConnectionPoolConfig.
 func SetConnectionPoolConfig(appName, database string, maxPoolSize, minPoolSize uint64) {
 	labels := []string{appName, database}
 	mongodbConnectionsMax.WithLabelValues(labels...).Set(float64(maxPoolSize))
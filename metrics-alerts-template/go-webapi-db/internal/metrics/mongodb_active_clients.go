@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type clientIDKey struct{}
+
+// ContextWithClientID attaches a caller identifier (e.g. user ID or session
+// ID) to ctx so RecordOperationCtx can count it toward
+// mongodb_active_clients_last_interval without every call site plumbing it
+// through explicitly. Typically called once by middleware near the top of
+// a request.
+func ContextWithClientID(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, clientIDKey{}, clientID)
+}
+
+func clientIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(clientIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// ActiveClientsCollector implements prometheus.Collector and reports the
+// number of distinct client identifiers that called RecordOperationCtx for
+// a given application/database pair within a trailing window. Entries
+// older than the window are pruned on every Collect, so the reported count
+// always reflects "active in the last window" rather than "active since
+// process start".
+type ActiveClientsCollector struct {
+	appName, database string
+	window            time.Duration
+
+	desc *prometheus.Desc
+
+	mu       sync.RWMutex
+	lastSeen map[string]time.Time
+}
+
+// NewActiveClientsCollector builds an ActiveClientsCollector. A window of
+// zero defaults to one hour.
+func NewActiveClientsCollector(appName, database string, window time.Duration) *ActiveClientsCollector {
+	if window <= 0 {
+		window = time.Hour
+	}
+	return &ActiveClientsCollector{
+		appName:  appName,
+		database: database,
+		window:   window,
+		desc: prometheus.NewDesc(
+			"mongodb_active_clients_last_interval",
+			"Number of distinct clients that issued a MongoDB operation within the configured rolling window",
+			[]string{"application", "database"}, nil,
+		),
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+// touch records clientID as seen now.
+func (c *ActiveClientsCollector) touch(clientID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastSeen[clientID] = time.Now()
+}
+
+func (c *ActiveClientsCollector) prune() int {
+	cutoff := time.Now().Add(-c.window)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, seen := range c.lastSeen {
+		if seen.Before(cutoff) {
+			delete(c.lastSeen, id)
+		}
+	}
+	return len(c.lastSeen)
+}
+
+// Describe implements prometheus.Collector.
+func (c *ActiveClientsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *ActiveClientsCollector) Collect(ch chan<- prometheus.Metric) {
+	count := c.prune()
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), c.appName, c.database)
+}
+
+// activeClientsCollectors backs the package-level RecordOperationCtx the
+// same way the mongodbOperations* vars back RecordOperation — lazily
+// creating and registering one ActiveClientsCollector per
+// application/database pair against prometheus.DefaultRegisterer the first
+// time that pair is seen. Prefer NewActiveClientsCollector directly with an
+// injected Registerer instead.
+var (
+	activeClientsMu         sync.Mutex
+	activeClientsCollectors = make(map[[2]string]*ActiveClientsCollector)
+)
+
+func activeClientsFor(appName, database string) *ActiveClientsCollector {
+	key := [2]string{appName, database}
+
+	activeClientsMu.Lock()
+	defer activeClientsMu.Unlock()
+
+	c, ok := activeClientsCollectors[key]
+	if !ok {
+		c = NewActiveClientsCollector(appName, database, 0)
+		prometheus.MustRegister(c)
+		activeClientsCollectors[key] = c
+	}
+	return c
+}
+
+// RecordOperationCtx behaves like RecordOperation but also counts the
+// operation's caller (via ContextWithClientID) toward
+// mongodb_active_clients_last_interval for this appName/database pair.
+// Operations with no attached client ID only update the usual
+// mongodb_operations_total/duration/error series.
+//
+// Deprecated: use (*MongoMetrics).RecordOperation.
+func RecordOperationCtx(ctx context.Context, appName, database, operation, collection string, duration time.Duration, err error) {
+	RecordOperation(appName, database, operation, collection, duration, err)
+	if clientID, ok := clientIDFromContext(ctx); ok {
+		activeClientsFor(appName, database).touch(clientID)
+	}
+}
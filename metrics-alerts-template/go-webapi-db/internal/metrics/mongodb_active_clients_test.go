@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestActiveClientsCollector_CountsDistinctClientsAndPrunes(t *testing.T) {
+	c := NewActiveClientsCollector("go-webapi-db", "test_db", 50*time.Millisecond)
+	c.touch("user-1")
+	c.touch("user-2")
+	c.touch("user-1") // repeat caller, must not double count
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	if got := gatherGauge(t, reg, "mongodb_active_clients_last_interval"); got != 2 {
+		t.Fatalf("mongodb_active_clients_last_interval = %v, want 2", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if got := gatherGauge(t, reg, "mongodb_active_clients_last_interval"); got != 0 {
+		t.Fatalf("mongodb_active_clients_last_interval after window = %v, want 0", got)
+	}
+}
+
+func TestRecordOperationCtx_TouchesActiveClientsOnlyWithClientID(t *testing.T) {
+	// RecordOperationCtx lazily registers its ActiveClientsCollector against
+	// prometheus.DefaultRegisterer, so use app/database labels unique to
+	// this test rather than trying to isolate a local Registry.
+	const app, db = "ctx-app-under-test", "ctx_db_under_test"
+
+	ctx := ContextWithClientID(context.Background(), "user-42")
+	RecordOperationCtx(ctx, app, db, "find", "users", time.Millisecond, nil)
+
+	if got := gatherDefaultGauge(t, app, db); got != 1 {
+		t.Fatalf("mongodb_active_clients_last_interval = %v, want 1", got)
+	}
+
+	RecordOperationCtx(context.Background(), app, db, "find", "users", time.Millisecond, nil)
+
+	if got := gatherDefaultGauge(t, app, db); got != 1 {
+		t.Fatalf("mongodb_active_clients_last_interval after anonymous call = %v, want unchanged at 1", got)
+	}
+}
+
+func gatherGauge(t *testing.T, reg *prometheus.Registry, name string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+		var total float64
+		for _, metric := range mf.GetMetric() {
+			total += metric.GetGauge().GetValue()
+		}
+		return total
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
+
+func gatherDefaultGauge(t *testing.T, application, database string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != "mongodb_active_clients_last_interval" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := make(map[string]string, len(metric.GetLabel()))
+			for _, l := range metric.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["application"] == application && labels["database"] == database {
+				return metric.GetGauge().GetValue()
+			}
+		}
+	}
+	t.Fatalf("metric mongodb_active_clients_last_interval{application=%q,database=%q} not found", application, database)
+	return 0
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DatastoreCollector implements prometheus.Collector by issuing a handful of
+// bounded MongoDB queries (total users, users by status, users created in
+// the last 24h) on every Collect call, rather than updating gauges on a
+// schedule the way MongoDBMetricsCollector does. It's meant to be
+// registered on its own prometheus.Registry and served from a dedicated
+// route (e.g. /metrics/db) so a slow or contended database can't stall the
+// fast /metrics endpoint used for RED metrics.
+type DatastoreCollector struct {
+	db      *mongo.Database
+	timeout time.Duration
+	logger  *slog.Logger
+
+	usersTotalDesc      *prometheus.Desc
+	usersByStatusDesc   *prometheus.Desc
+	usersCreated24hDesc *prometheus.Desc
+}
+
+// NewDatastoreCollector creates a DatastoreCollector that bounds every query
+// a single Collect call issues against db to timeout.
+func NewDatastoreCollector(db *mongo.Database, timeout time.Duration) *DatastoreCollector {
+	return &DatastoreCollector{
+		db:      db,
+		timeout: timeout,
+		usersTotalDesc: prometheus.NewDesc(
+			"datastore_users_total", "Total number of users in the datastore", nil, nil,
+		),
+		usersByStatusDesc: prometheus.NewDesc(
+			"datastore_users_by_status", "Number of users per status", []string{"status"}, nil,
+		),
+		usersCreated24hDesc: prometheus.NewDesc(
+			"datastore_users_created_last_24h", "Number of users created in the last 24 hours", nil, nil,
+		),
+	}
+}
+
+// WithLogger attaches logger so a failing/slow query is also logged.
+// Returns c for chaining.
+func (c *DatastoreCollector) WithLogger(logger *slog.Logger) *DatastoreCollector {
+	c.logger = logger
+	return c
+}
+
+// Describe implements prometheus.Collector. The users-by-status breakdown's
+// label values aren't known ahead of a query, so Describe intentionally
+// sends no descriptors - the same unchecked-collector pattern as
+// UsersStatAggregator.
+func (c *DatastoreCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector, issuing bounded queries against
+// the datastore on every call. A query that errors or exceeds timeout is
+// logged and its series is skipped rather than blocking or panicking the
+// scrape.
+func (c *DatastoreCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	users := c.db.Collection("users")
+
+	if total, err := users.CountDocuments(ctx, bson.M{}); err != nil {
+		c.logQueryError("count total users", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.usersTotalDesc, prometheus.GaugeValue, float64(total))
+	}
+
+	statuses, err := users.Distinct(ctx, "status", bson.M{})
+	if err != nil {
+		c.logQueryError("list distinct user statuses", err)
+	} else {
+		for _, raw := range statuses {
+			status, _ := raw.(string)
+			count, err := users.CountDocuments(ctx, bson.M{"status": status})
+			if err != nil {
+				c.logQueryError("count users by status", err)
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.usersByStatusDesc, prometheus.GaugeValue, float64(count), status)
+		}
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	if created, err := users.CountDocuments(ctx, bson.M{"created_at": bson.M{"$gte": since}}); err != nil {
+		c.logQueryError("count users created in last 24h", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.usersCreated24hDesc, prometheus.GaugeValue, float64(created))
+	}
+}
+
+func (c *DatastoreCollector) logQueryError(action string, err error) {
+	if c.logger != nil {
+		c.logger.Warn("datastore collector query failed", "action", action, "error", err)
+	}
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestDatastoreCollector_CollectDoesNotBlockOnFailingQueries(t *testing.T) {
+	client, _ := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	defer client.Disconnect(context.Background())
+
+	collector := NewDatastoreCollector(client.Database("test_db"), 50*time.Millisecond)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collector)
+
+	// No live MongoDB is required for this test: every query in Collect
+	// either times out or errors against the unconnected client, and
+	// Collect must log and skip rather than block or panic the scrape.
+	if _, err := reg.Gather(); err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// DBStatsCollector implements prometheus.Collector directly, mirroring
+// prometheus/client_golang's collectors.NewDBStatsCollector for
+// database/sql: rather than a background goroutine periodically writing
+// gauges, it accumulates pool-event-derived counters and renders them on
+// every Collect call, so values are always as fresh as the last scrape.
+// Construct one alongside NewMonitoredClientOptions and register it with
+// the same Registerer used for the rest of the application's metrics.
+type DBStatsCollector struct {
+	appName, database string
+
+	maxIdleClosed     atomic.Int64
+	maxLifetimeClosed atomic.Int64
+	waitCount         atomic.Int64
+	waitDurationNanos atomic.Int64
+
+	maxIdleClosedDesc     *prometheus.Desc
+	maxLifetimeClosedDesc *prometheus.Desc
+	waitCountDesc         *prometheus.Desc
+	waitDurationDesc      *prometheus.Desc
+}
+
+func newDBStatsCollector(appName, database string) *DBStatsCollector {
+	labelNames := []string{"application", "database"}
+	return &DBStatsCollector{
+		appName:  appName,
+		database: database,
+		maxIdleClosedDesc: prometheus.NewDesc(
+			"mongodb_pool_max_idle_closed_total",
+			"Total number of connections closed because the pool had too many idle connections",
+			labelNames, nil,
+		),
+		maxLifetimeClosedDesc: prometheus.NewDesc(
+			"mongodb_pool_max_lifetime_closed_total",
+			"Total number of connections closed because they exceeded their max lifetime",
+			labelNames, nil,
+		),
+		waitCountDesc: prometheus.NewDesc(
+			"mongodb_pool_wait_count_total",
+			"Total number of connection checkouts that had to wait for a connection to become available",
+			labelNames, nil,
+		),
+		waitDurationDesc: prometheus.NewDesc(
+			"mongodb_pool_wait_duration_seconds_total",
+			"Total time spent waiting for a connection to become available",
+			labelNames, nil,
+		),
+	}
+}
+
+// recordClosed classifies a ConnectionClosed event's reason into the
+// derived max-idle/max-lifetime counters. Connections closed for other
+// reasons (error, poolClosed) are not counted by this collector, nor by
+// mongodb_connection_errors_total, which counts failed checkouts and
+// heartbeats rather than closed connections.
+func (c *DBStatsCollector) recordClosed(reason event.Reason) {
+	switch reason {
+	case event.ReasonIdle:
+		c.maxIdleClosed.Add(1)
+	case event.ReasonStale:
+		c.maxLifetimeClosed.Add(1)
+	}
+}
+
+func (c *DBStatsCollector) recordWait(waited bool, duration time.Duration) {
+	if !waited {
+		return
+	}
+	c.waitCount.Add(1)
+	c.waitDurationNanos.Add(duration.Nanoseconds())
+}
+
+// Describe implements prometheus.Collector.
+func (c *DBStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxIdleClosedDesc
+	ch <- c.maxLifetimeClosedDesc
+	ch <- c.waitCountDesc
+	ch <- c.waitDurationDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *DBStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	labels := []string{c.appName, c.database}
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosedDesc, prometheus.CounterValue, float64(c.maxIdleClosed.Load()), labels...)
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosedDesc, prometheus.CounterValue, float64(c.maxLifetimeClosed.Load()), labels...)
+	ch <- prometheus.MustNewConstMetric(c.waitCountDesc, prometheus.CounterValue, float64(c.waitCount.Load()), labels...)
+	ch <- prometheus.MustNewConstMetric(c.waitDurationDesc, prometheus.CounterValue, float64(c.waitDurationNanos.Load())/1e9, labels...)
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestDBStatsCollector_RecordsDerivedCounters(t *testing.T) {
+	c := newDBStatsCollector("go-webapi-db", "test_db")
+	c.recordClosed(event.ReasonIdle)
+	c.recordClosed(event.ReasonStale)
+	c.recordWait(true, 50*time.Millisecond)
+	c.recordWait(false, time.Second) // not a wait, must be ignored
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	values := make(map[string]float64, len(families))
+	for _, mf := range families {
+		for _, metric := range mf.GetMetric() {
+			values[mf.GetName()] += metric.GetCounter().GetValue()
+		}
+	}
+
+	if got := values["mongodb_pool_max_idle_closed_total"]; got != 1 {
+		t.Errorf("mongodb_pool_max_idle_closed_total = %v, want 1", got)
+	}
+	if got := values["mongodb_pool_max_lifetime_closed_total"]; got != 1 {
+		t.Errorf("mongodb_pool_max_lifetime_closed_total = %v, want 1", got)
+	}
+	if got := values["mongodb_pool_wait_count_total"]; got != 1 {
+		t.Errorf("mongodb_pool_wait_count_total = %v, want 1", got)
+	}
+	if got := values["mongodb_pool_wait_duration_seconds_total"]; got < 0.049 || got > 0.051 {
+		t.Errorf("mongodb_pool_wait_duration_seconds_total = %v, want ~0.05", got)
+	}
+}
@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrorDedupHandler wraps a slog.Handler and suppresses repeated MongoDB
+// error records sharing the same error_type/collection attribute pair
+// within window, so a hot failure mode (e.g. a single collection timing
+// out on every call) doesn't drown the log while the corresponding
+// Prometheus counters keep incrementing normally - suppression only
+// affects log output. The first record for a key within the window is
+// always forwarded; on Flush, any key that suppressed at least one record
+// since its last flush gets a single "suppressed=K" summary record.
+//
+// Unlike the general-purpose logger.DedupHandler (keyed on level+message),
+// this dedups on the structured attributes RecordOperation/
+// RecordConnectionError/SlowOpCollector actually vary by.
+type ErrorDedupHandler struct {
+	next   slog.Handler
+	window time.Duration
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+
+	// mu and entries are shared by pointer with every handler WithAttrs/
+	// WithGroup derive from this one, so concurrent slog.With calls still
+	// guard the same entries map instead of each getting its own zero-value
+	// mutex over a map they all alias.
+	mu      *sync.Mutex
+	entries map[errorDedupKey]*errorDedupEntry
+}
+
+type errorDedupKey struct {
+	errorType  string
+	collection string
+}
+
+type errorDedupEntry struct {
+	firstSeen  time.Time
+	suppressed int
+}
+
+// NewErrorDedupHandler returns a handler that forwards to next but drops
+// repeats of the same error_type/collection pair seen within window.
+func NewErrorDedupHandler(next slog.Handler, window time.Duration) *ErrorDedupHandler {
+	return &ErrorDedupHandler{
+		next:    next,
+		window:  window,
+		stopCh:  make(chan struct{}),
+		mu:      &sync.Mutex{},
+		entries: make(map[errorDedupKey]*errorDedupEntry),
+	}
+}
+
+// Start begins periodically flushing suppressed-record summaries.
+func (h *ErrorDedupHandler) Start(interval time.Duration) {
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				h.Flush()
+			case <-h.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic flush loop and flushes any pending summaries.
+func (h *ErrorDedupHandler) Stop() {
+	close(h.stopCh)
+	h.wg.Wait()
+	h.Flush()
+}
+
+func (h *ErrorDedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *ErrorDedupHandler) Handle(ctx context.Context, record slog.Record) error {
+	key, ok := errorDedupKeyOf(record)
+	if !ok {
+		return h.next.Handle(ctx, record)
+	}
+
+	now := time.Now()
+
+	h.mu.Lock()
+	entry, seen := h.entries[key]
+	switch {
+	case !seen:
+		h.entries[key] = &errorDedupEntry{firstSeen: now}
+	case now.Sub(entry.firstSeen) < h.window:
+		entry.suppressed++
+		h.mu.Unlock()
+		return nil
+	default:
+		entry.firstSeen = now
+		entry.suppressed = 0
+	}
+	h.mu.Unlock()
+
+	return h.next.Handle(ctx, record)
+}
+
+// Flush emits one summary record per key that suppressed at least one
+// record since the last flush, then resets its counter.
+func (h *ErrorDedupHandler) Flush() {
+	h.mu.Lock()
+	type summary struct {
+		key        errorDedupKey
+		suppressed int
+	}
+	var due []summary
+	for key, entry := range h.entries {
+		if entry.suppressed > 0 {
+			due = append(due, summary{key, entry.suppressed})
+			entry.suppressed = 0
+		}
+	}
+	h.mu.Unlock()
+
+	for _, s := range due {
+		record := slog.NewRecord(time.Now(), slog.LevelWarn, "suppressed repeated mongodb errors", 0)
+		record.AddAttrs(
+			slog.String("error_type", s.key.errorType),
+			slog.String("collection", s.key.collection),
+			slog.Int("suppressed", s.suppressed),
+		)
+		_ = h.next.Handle(context.Background(), record)
+	}
+}
+
+func (h *ErrorDedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ErrorDedupHandler{next: h.next.WithAttrs(attrs), window: h.window, stopCh: h.stopCh, mu: h.mu, entries: h.entries}
+}
+
+func (h *ErrorDedupHandler) WithGroup(name string) slog.Handler {
+	return &ErrorDedupHandler{next: h.next.WithGroup(name), window: h.window, stopCh: h.stopCh, mu: h.mu, entries: h.entries}
+}
+
+// errorDedupKeyOf extracts a dedup key from record: error_type+collection
+// when both are present (RecordOperation), error_type alone when there is
+// no collection (RecordConnectionError), and message+collection when there
+// is no error_type (SlowOpCollector logs a collection but has no error_type
+// to vary by). Records with neither attribute (e.g. plain lifecycle info
+// logs) aren't deduped.
+func errorDedupKeyOf(record slog.Record) (errorDedupKey, bool) {
+	var key errorDedupKey
+	var errorType string
+	var hasErrorType, hasCollection bool
+
+	record.Attrs(func(attr slog.Attr) bool {
+		switch attr.Key {
+		case "error_type":
+			errorType = attr.Value.String()
+			hasErrorType = true
+		case "collection":
+			key.collection = attr.Value.String()
+			hasCollection = true
+		}
+		return true
+	})
+	if !hasCollection && !hasErrorType {
+		return key, false
+	}
+
+	if hasErrorType {
+		key.errorType = errorType
+	} else {
+		key.errorType = record.Message
+	}
+	return key, true
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingHandler struct {
+	records []slog.Record
+}
+
+func (h *countingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *countingHandler) Handle(_ context.Context, record slog.Record) error {
+	h.records = append(h.records, record)
+	return nil
+}
+
+func (h *countingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *countingHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestErrorDedupHandler_SuppressesSameKeyWithinWindow(t *testing.T) {
+	next := &countingHandler{}
+	h := NewErrorDedupHandler(next, time.Hour)
+	l := slog.New(h)
+
+	l.Warn("mongodb operation failed", "error_type", "timeout", "collection", "users")
+	l.Warn("mongodb operation failed", "error_type", "timeout", "collection", "users")
+	l.Warn("mongodb operation failed", "error_type", "timeout", "collection", "users")
+
+	if len(next.records) != 1 {
+		t.Fatalf("expected 1 forwarded record within the dedup window, got %d", len(next.records))
+	}
+}
+
+func TestErrorDedupHandler_DistinctKeysNotDeduped(t *testing.T) {
+	next := &countingHandler{}
+	h := NewErrorDedupHandler(next, time.Hour)
+	l := slog.New(h)
+
+	l.Warn("mongodb operation failed", "error_type", "timeout", "collection", "users")
+	l.Warn("mongodb operation failed", "error_type", "not_found", "collection", "users")
+	l.Warn("mongodb operation failed", "error_type", "timeout", "collection", "orders")
+
+	if len(next.records) != 3 {
+		t.Fatalf("expected 3 forwarded records for 3 distinct error_type/collection pairs, got %d", len(next.records))
+	}
+}
+
+func TestErrorDedupHandler_UnkeyedRecordsPassThrough(t *testing.T) {
+	next := &countingHandler{}
+	h := NewErrorDedupHandler(next, time.Hour)
+	l := slog.New(h)
+
+	l.Info("mongodb metrics collector started")
+	l.Info("mongodb metrics collector started")
+
+	if len(next.records) != 2 {
+		t.Fatalf("expected records without error_type/collection to always pass through, got %d", len(next.records))
+	}
+}
+
+func TestErrorDedupHandler_FlushEmitsSuppressedSummary(t *testing.T) {
+	next := &countingHandler{}
+	h := NewErrorDedupHandler(next, time.Hour)
+	l := slog.New(h)
+
+	l.Warn("mongodb operation failed", "error_type", "timeout", "collection", "users")
+	l.Warn("mongodb operation failed", "error_type", "timeout", "collection", "users")
+	l.Warn("mongodb operation failed", "error_type", "timeout", "collection", "users")
+
+	h.Flush()
+
+	if len(next.records) != 2 {
+		t.Fatalf("expected the original record plus one summary record after Flush, got %d", len(next.records))
+	}
+
+	summary := next.records[1]
+	if !strings.Contains(summary.Message, "suppressed") {
+		t.Errorf("expected flush summary message to mention suppression, got %q", summary.Message)
+	}
+
+	var suppressed int64 = -1
+	summary.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == "suppressed" {
+			suppressed = attr.Value.Int64()
+		}
+		return true
+	})
+	if suppressed != 2 {
+		t.Errorf("suppressed = %d, want 2", suppressed)
+	}
+
+	// A second flush with nothing new suppressed must not re-emit.
+	h.Flush()
+	if len(next.records) != 2 {
+		t.Errorf("expected no additional summary from an idle Flush, got %d records", len(next.records))
+	}
+}
+
+func TestErrorDedupHandler_AllowsRecordAfterWindowElapses(t *testing.T) {
+	next := &countingHandler{}
+	h := NewErrorDedupHandler(next, 50*time.Millisecond)
+	l := slog.New(h)
+
+	l.Warn("mongodb operation failed", "error_type", "timeout", "collection", "users")
+	time.Sleep(60 * time.Millisecond)
+	l.Warn("mongodb operation failed", "error_type", "timeout", "collection", "users")
+
+	if len(next.records) != 2 {
+		t.Fatalf("expected a 2nd forwarded record after the dedup window elapsed, got %d", len(next.records))
+	}
+}
+
+// TestErrorDedupHandler_WithAttrsSharesMutex guards against WithAttrs/
+// WithGroup handing out a fresh zero-value mutex over the still-shared
+// entries map - every derived handler must serialize on the same lock the
+// original handler uses, or concurrent callers race on the map. Run with
+// -race to catch a regression.
+func TestErrorDedupHandler_WithAttrsSharesMutex(t *testing.T) {
+	h := NewErrorDedupHandler(slog.NewJSONHandler(io.Discard, nil), time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			derived := h.WithAttrs([]slog.Attr{slog.Int("worker", i)})
+			record := slog.NewRecord(time.Now(), slog.LevelWarn, "mongodb operation failed", 0)
+			record.AddAttrs(slog.String("error_type", "timeout"), slog.String("collection", "users"))
+			_ = derived.Handle(context.Background(), record)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,257 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoMetrics owns the MongoDB collectors for one registry scope,
+// registered against an injected prometheus.Registerer the same way
+// Registry does for HTTP RED metrics. Prefer this over the package-level
+// RecordOperation/RecordPing/etc. functions (which remain for existing
+// callers but are deprecated), since those are bound to promauto's default
+// registry and can't be isolated in tests or scoped per embedding app.
+type MongoMetrics struct {
+	connectionsActive *prometheus.GaugeVec
+	connectionsIdle   *prometheus.GaugeVec
+	connectionsMax    *prometheus.GaugeVec
+	connectionsMin    *prometheus.GaugeVec
+	connectionsTotal  *prometheus.GaugeVec
+
+	connectionAcquireDuration *prometheus.HistogramVec
+	connectionTimeouts        *prometheus.CounterVec
+
+	operationsTotal   *prometheus.CounterVec
+	operationDuration *prometheus.HistogramVec
+	operationErrors   *prometheus.CounterVec
+	connectionErrors  *prometheus.CounterVec
+	pingDuration      *prometheus.HistogramVec
+
+	logger *slog.Logger
+}
+
+// WithLogger attaches logger so operation errors, connection timeouts/
+// errors, and other notable events are also emitted as structured log
+// records with the same application/database/collection/operation
+// attributes as the corresponding series, alongside (not instead of) the
+// Prometheus metrics. Returns m for chaining, e.g.:
+//
+//	mm := metrics.NewMongoMetrics(registry).WithLogger(log)
+func (m *MongoMetrics) WithLogger(logger *slog.Logger) *MongoMetrics {
+	m.logger = logger
+	return m
+}
+
+// NewMongoMetrics builds a MongoMetrics and registers its collectors
+// against reg.
+func NewMongoMetrics(reg prometheus.Registerer) *MongoMetrics {
+	m := &MongoMetrics{
+		connectionsActive: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "mongodb_connections_active",
+				Help: "Number of active MongoDB connections",
+			},
+			[]string{"application", "database"},
+		),
+		connectionsIdle: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "mongodb_connections_idle",
+				Help: "Number of idle MongoDB connections",
+			},
+			[]string{"application", "database"},
+		),
+		connectionsMax: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "mongodb_connections_max",
+				Help: "Maximum number of MongoDB connections allowed",
+			},
+			[]string{"application", "database"},
+		),
+		connectionsMin: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "mongodb_connections_min",
+				Help: "Minimum number of MongoDB connections maintained",
+			},
+			[]string{"application", "database"},
+		),
+		connectionsTotal: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "mongodb_connections_total",
+				Help: "Total number of MongoDB connections in the pool",
+			},
+			[]string{"application", "database"},
+		),
+		connectionAcquireDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "mongodb_connection_acquire_seconds",
+				Help:    "Time taken to acquire a MongoDB connection",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+			},
+			[]string{"application", "database"},
+		),
+		connectionTimeouts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mongodb_connection_timeouts_total",
+				Help: "Total number of MongoDB connection acquisition timeouts",
+			},
+			[]string{"application", "database"},
+		),
+		operationsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mongodb_operations_total",
+				Help: "Total number of MongoDB operations",
+			},
+			[]string{"application", "database", "operation", "collection"},
+		),
+		operationDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "mongodb_operation_duration_seconds",
+				Help:    "Duration of MongoDB operations in seconds",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+			},
+			[]string{"application", "database", "operation", "collection"},
+		),
+		operationErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mongodb_operation_errors_total",
+				Help: "Total number of MongoDB operation errors",
+			},
+			[]string{"application", "database", "operation", "collection", "error_type"},
+		),
+		connectionErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mongodb_connection_errors_total",
+				Help: "Total number of MongoDB connection errors",
+			},
+			[]string{"application", "database", "error_type"},
+		),
+		pingDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "mongodb_ping_duration_seconds",
+				Help:    "MongoDB ping duration in seconds",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5},
+			},
+			[]string{"application", "database"},
+		),
+	}
+
+	reg.MustRegister(
+		m.connectionsActive,
+		m.connectionsIdle,
+		m.connectionsMax,
+		m.connectionsMin,
+		m.connectionsTotal,
+		m.connectionAcquireDuration,
+		m.connectionTimeouts,
+		m.operationsTotal,
+		m.operationDuration,
+		m.operationErrors,
+		m.connectionErrors,
+		m.pingDuration,
+	)
+	return m
+}
+
+// RecordOperation records a MongoDB operation.
+func (m *MongoMetrics) RecordOperation(appName, database, operation, collection string, duration time.Duration, err error) {
+	labels := []string{appName, database, operation, collection}
+
+	m.operationsTotal.WithLabelValues(labels...).Inc()
+	m.operationDuration.WithLabelValues(labels...).Observe(duration.Seconds())
+
+	if err != nil {
+		errorType := "unknown"
+		if err == mongo.ErrNoDocuments {
+			errorType = "not_found"
+		} else if err == context.DeadlineExceeded {
+			errorType = "timeout"
+		} else if err == context.Canceled {
+			errorType = "cancelled"
+		}
+
+		errorLabels := append(labels, errorType)
+		m.operationErrors.WithLabelValues(errorLabels...).Inc()
+
+		if m.logger != nil {
+			m.logger.Warn("mongodb operation failed",
+				"application", appName, "database", database, "operation", operation,
+				"collection", collection, "duration_ms", duration.Milliseconds(), "error_type", errorType)
+		}
+	}
+}
+
+// RecordConnectionAcquisition records connection acquisition time.
+func (m *MongoMetrics) RecordConnectionAcquisition(appName, database string, duration time.Duration, timeout bool) {
+	labels := []string{appName, database}
+
+	if timeout {
+		m.connectionTimeouts.WithLabelValues(labels...).Inc()
+		if m.logger != nil {
+			m.logger.Warn("mongodb connection acquisition timed out",
+				"application", appName, "database", database, "duration_ms", duration.Milliseconds())
+		}
+	} else {
+		m.connectionAcquireDuration.WithLabelValues(labels...).Observe(duration.Seconds())
+	}
+}
+
+// RecordConnectionError records a connection error.
+func (m *MongoMetrics) RecordConnectionError(appName, database, errorType string) {
+	m.connectionErrors.WithLabelValues(appName, database, errorType).Inc()
+	if m.logger != nil {
+		m.logger.Warn("mongodb connection error", "application", appName, "database", database, "error_type", errorType)
+	}
+}
+
+// RecordPing records a MongoDB ping operation.
+func (m *MongoMetrics) RecordPing(appName, database string, duration time.Duration) {
+	m.pingDuration.WithLabelValues(appName, database).Observe(duration.Seconds())
+}
+
+// IncConnectionsTotal records a new pooled connection being created.
+func (m *MongoMetrics) IncConnectionsTotal(appName, database string) {
+	m.connectionsTotal.WithLabelValues(appName, database).Inc()
+}
+
+// DecConnectionsTotal records a pooled connection being closed.
+func (m *MongoMetrics) DecConnectionsTotal(appName, database string) {
+	m.connectionsTotal.WithLabelValues(appName, database).Dec()
+}
+
+// IncActive and DecActive record a connection moving into and out of active
+// use as it's checked out of and back into the pool.
+func (m *MongoMetrics) IncActive(appName, database string) {
+	m.connectionsActive.WithLabelValues(appName, database).Inc()
+}
+
+func (m *MongoMetrics) DecActive(appName, database string) {
+	m.connectionsActive.WithLabelValues(appName, database).Dec()
+}
+
+// IncIdle and DecIdle record a connection moving into and out of the idle
+// pool, the inverse of IncActive/DecActive for the same checkout.
+func (m *MongoMetrics) IncIdle(appName, database string) {
+	m.connectionsIdle.WithLabelValues(appName, database).Inc()
+}
+
+func (m *MongoMetrics) DecIdle(appName, database string) {
+	m.connectionsIdle.WithLabelValues(appName, database).Dec()
+}
+
+// ResetActiveIdle zeroes the active/idle gauges, e.g. when the driver clears
+// the pool and every existing connection is discarded at once.
+func (m *MongoMetrics) ResetActiveIdle(appName, database string) {
+	m.connectionsActive.WithLabelValues(appName, database).Set(0)
+	m.connectionsIdle.WithLabelValues(appName, database).Set(0)
+}
+
+// SetConnectionPoolConfig sets the connection pool configuration metrics.
+func (m *MongoMetrics) SetConnectionPoolConfig(appName, database string, maxPoolSize, minPoolSize uint64) {
+	labels := []string{appName, database}
+	m.connectionsMax.WithLabelValues(labels...).Set(float64(maxPoolSize))
+	m.connectionsMin.WithLabelValues(labels...).Set(float64(minPoolSize))
+}
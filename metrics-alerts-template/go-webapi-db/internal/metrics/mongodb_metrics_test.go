@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMongoMetrics_RecordOperation(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewMongoMetrics(reg)
+
+	m.RecordOperation("go-webapi-db", "test_db", "find", "users", 10*time.Millisecond, nil)
+	m.RecordOperation("go-webapi-db", "test_db", "find", "users", 5*time.Millisecond, mongo.ErrNoDocuments)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	values := make(map[string]float64, len(families))
+	for _, mf := range families {
+		for _, metric := range mf.GetMetric() {
+			values[mf.GetName()] += metric.GetCounter().GetValue()
+		}
+	}
+
+	if got := values["mongodb_operations_total"]; got != 2 {
+		t.Errorf("mongodb_operations_total = %v, want 2", got)
+	}
+	if got := values["mongodb_operation_errors_total"]; got != 1 {
+		t.Errorf("mongodb_operation_errors_total = %v, want 1", got)
+	}
+}
+
+func TestMongoMetrics_SetConnectionPoolConfig(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewMongoMetrics(reg)
+
+	m.SetConnectionPoolConfig("go-webapi-db", "test_db", 100, 5)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	values := make(map[string]float64, len(families))
+	for _, mf := range families {
+		for _, metric := range mf.GetMetric() {
+			values[mf.GetName()] += metric.GetGauge().GetValue()
+		}
+	}
+
+	if got := values["mongodb_connections_max"]; got != 100 {
+		t.Errorf("mongodb_connections_max = %v, want 100", got)
+	}
+	if got := values["mongodb_connections_min"]; got != 5 {
+		t.Errorf("mongodb_connections_min = %v, want 5", got)
+	}
+}
+
+func TestMongoMetrics_IsolatedPerRegistry(t *testing.T) {
+	regA := prometheus.NewRegistry()
+	regB := prometheus.NewRegistry()
+	mA := NewMongoMetrics(regA)
+	_ = NewMongoMetrics(regB)
+
+	mA.RecordPing("go-webapi-db", "test_db", time.Millisecond)
+
+	familiesA, _ := regA.Gather()
+	familiesB, _ := regB.Gather()
+
+	var countA, countB int
+	for _, mf := range familiesA {
+		if mf.GetName() == "mongodb_ping_duration_seconds" {
+			countA = len(mf.GetMetric())
+		}
+	}
+	for _, mf := range familiesB {
+		if mf.GetName() == "mongodb_ping_duration_seconds" {
+			countB = len(mf.GetMetric())
+		}
+	}
+
+	if countA != 1 {
+		t.Errorf("expected regA to have 1 ping series, got %d", countA)
+	}
+	if countB != 0 {
+		t.Errorf("expected regB to have 0 ping series, got %d", countB)
+	}
+}
+
+func TestMongoMetrics_WithLoggerLogsOperationErrors(t *testing.T) {
+	var buf bytes.Buffer
+	reg := prometheus.NewRegistry()
+	m := NewMongoMetrics(reg).WithLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	m.RecordOperation("go-webapi-db", "test_db", "find", "users", 5*time.Millisecond, nil)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no log record for a successful operation, got %s", buf.String())
+	}
+
+	m.RecordOperation("go-webapi-db", "test_db", "find", "users", 5*time.Millisecond, mongo.ErrNoDocuments)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON log record, got error: %v (%s)", err, buf.String())
+	}
+	if entry["error_type"] != "not_found" {
+		t.Errorf("error_type = %v, want not_found", entry["error_type"])
+	}
+	if entry["collection"] != "users" {
+		t.Errorf("collection = %v, want users", entry["collection"])
+	}
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NewMonitoredClientOptions returns *options.ClientOptions with a
+// PoolMonitor and ServerMonitor wired up so mm's connections-active,
+// connections-idle, connections-total, connection-acquire-duration,
+// connection-timeouts, connection-errors, and ping-duration series track
+// the driver's real pool and heartbeat events instead of the static values
+// SetConnectionPoolConfig contributes. mm must already be registered against
+// the application's Registerer (via NewMongoMetrics). It also returns a
+// DBStatsCollector that must be registered with the application's Registerer
+// to expose the derived mongodb_pool_* series. Compose the options with
+// ApplyURI and the pool-size setters, e.g.:
+//
+//	mm := metrics.NewMongoMetrics(registry)
+//	opts, dbStats := metrics.NewMonitoredClientOptions(appName, database, mm)
+//	opts.ApplyURI(cfg.MongoDB.URI).SetMaxPoolSize(cfg.MongoDB.MaxPoolSize)
+//	registry.MustRegister(dbStats)
+func NewMonitoredClientOptions(appName, database string, mm *MongoMetrics) (*options.ClientOptions, *DBStatsCollector) {
+	tracker := newCheckoutTracker()
+	dbStats := newDBStatsCollector(appName, database)
+
+	poolMonitor := &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			switch evt.Type {
+			case event.ConnectionCreated:
+				mm.IncConnectionsTotal(appName, database)
+			case event.ConnectionClosed:
+				mm.DecConnectionsTotal(appName, database)
+				dbStats.recordClosed(evt.Reason)
+			case event.ConnectionCheckOutStarted:
+				tracker.start(evt.Address)
+			case event.ConnectionCheckedOut:
+				if start, ok := tracker.finish(evt.Address); ok {
+					waited := time.Since(start)
+					mm.RecordConnectionAcquisition(appName, database, waited, false)
+					dbStats.recordWait(waited > 0, waited)
+				}
+				mm.IncActive(appName, database)
+				mm.DecIdle(appName, database)
+			case event.ConnectionCheckedIn:
+				mm.DecActive(appName, database)
+				mm.IncIdle(appName, database)
+			case event.ConnectionCheckOutFailed:
+				tracker.finish(evt.Address)
+				switch evt.Reason {
+				case event.ReasonTimedOut:
+					mm.RecordConnectionAcquisition(appName, database, 0, true)
+				case event.ReasonConnectionErrored:
+					mm.RecordConnectionError(appName, database, "connectionError")
+				case event.ReasonPoolClosed:
+					mm.RecordConnectionError(appName, database, "poolClosed")
+				default:
+					mm.RecordConnectionError(appName, database, "unknown")
+				}
+			case event.PoolCleared:
+				mm.ResetActiveIdle(appName, database)
+			}
+		},
+	}
+
+	serverMonitor := &event.ServerMonitor{
+		ServerHeartbeatSucceeded: func(evt *event.ServerHeartbeatSucceededEvent) {
+			mm.RecordPing(appName, database, evt.Duration)
+		},
+		ServerHeartbeatFailed: func(evt *event.ServerHeartbeatFailedEvent) {
+			mm.RecordConnectionError(appName, database, "heartbeatFailed")
+		},
+	}
+
+	opts := options.Client().SetPoolMonitor(poolMonitor).SetServerMonitor(serverMonitor)
+	return opts, dbStats
+}
+
+// checkoutTracker correlates ConnectionCheckOutStarted with its eventual
+// ConnectionCheckedOut/ConnectionCheckOutFailed so acquisition latency can
+// be observed. PoolEvent carries no per-checkout ID, so checkouts against
+// the same server address are matched FIFO, which is exact for the common
+// case of one in-flight checkout per address and a reasonable approximation
+// under concurrent checkouts.
+type checkoutTracker struct {
+	mu      sync.Mutex
+	started map[string][]time.Time
+}
+
+func newCheckoutTracker() *checkoutTracker {
+	return &checkoutTracker{started: make(map[string][]time.Time)}
+}
+
+func (t *checkoutTracker) start(address string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.started[address] = append(t.started[address], time.Now())
+}
+
+func (t *checkoutTracker) finish(address string) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	queue := t.started[address]
+	if len(queue) == 0 {
+		return time.Time{}, false
+	}
+	start := queue[0]
+	t.started[address] = queue[1:]
+	return start, true
+}
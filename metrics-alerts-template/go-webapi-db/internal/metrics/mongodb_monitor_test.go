@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCheckoutTracker_FIFOMatchPerAddress(t *testing.T) {
+	tracker := newCheckoutTracker()
+
+	if _, ok := tracker.finish("host:27017"); ok {
+		t.Fatal("expected no match before any start")
+	}
+
+	tracker.start("host:27017")
+	tracker.start("host:27017")
+
+	first, ok := tracker.finish("host:27017")
+	if !ok {
+		t.Fatal("expected first checkout to match")
+	}
+
+	second, ok := tracker.finish("host:27017")
+	if !ok {
+		t.Fatal("expected second checkout to match")
+	}
+	if second.Before(first) {
+		t.Error("expected checkouts to be matched in start order")
+	}
+
+	if _, ok := tracker.finish("host:27017"); ok {
+		t.Error("expected no more matches after the queue is drained")
+	}
+}
+
+func TestCheckoutTracker_IsolatedPerAddress(t *testing.T) {
+	tracker := newCheckoutTracker()
+
+	tracker.start("host-a:27017")
+
+	if _, ok := tracker.finish("host-b:27017"); ok {
+		t.Error("expected no match for a different address")
+	}
+	if _, ok := tracker.finish("host-a:27017"); !ok {
+		t.Error("expected the original address's checkout to still be pending")
+	}
+}
+
+func TestNewMonitoredClientOptions_SetsMonitors(t *testing.T) {
+	mm := NewMongoMetrics(prometheus.NewRegistry())
+	opts, dbStats := NewMonitoredClientOptions("go-webapi-db", "test_db", mm)
+
+	if opts.PoolMonitor == nil {
+		t.Error("expected PoolMonitor to be set")
+	}
+	if opts.ServerMonitor == nil {
+		t.Error("expected ServerMonitor to be set")
+	}
+	if dbStats == nil {
+		t.Error("expected a non-nil DBStatsCollector")
+	}
+}
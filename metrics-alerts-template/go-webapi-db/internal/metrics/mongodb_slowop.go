@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	mongodbCurrentOpQueryUptime = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mongodb_currentop_query_uptime_seconds",
+			Help: "How long each in-progress MongoDB operation slower than the configured threshold has been running",
+		},
+		[]string{"application", "database", "collection", "ns", "op", "desc", "opid"},
+	)
+
+	mongodbCurrentOpParseErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mongodb_currentop_parse_errors_total",
+			Help: "Total number of currentOp \"inprog\" entries that could not be decoded",
+		},
+		[]string{"application"},
+	)
+)
+
+// SlowOpCollector periodically runs the currentOp admin command, filtered to
+// operations that have been running longer than threshold, and publishes
+// one mongodb_currentop_query_uptime_seconds series per in-progress slow
+// op so they're visible to alerting without waiting on profiler log
+// ingestion.
+type SlowOpCollector struct {
+	client    *mongo.Client
+	appName   string
+	threshold time.Duration
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	logger    *slog.Logger
+
+	mu         sync.Mutex
+	lastLabels [][]string
+}
+
+// NewSlowOpCollector creates a SlowOpCollector that reports ops still
+// running after threshold (e.g. 100*time.Millisecond).
+func NewSlowOpCollector(client *mongo.Client, appName string, threshold time.Duration) *SlowOpCollector {
+	return &SlowOpCollector{
+		client:    client,
+		appName:   appName,
+		threshold: threshold,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// WithLogger attaches logger so every slow op found above threshold is also
+// emitted as a structured log record (database, collection, operation,
+// duration_ms, opid), alongside mongodb_currentop_query_uptime_seconds.
+// Returns c for chaining.
+func (c *SlowOpCollector) WithLogger(logger *slog.Logger) *SlowOpCollector {
+	c.logger = logger
+	return c
+}
+
+// Start begins scraping currentOp periodically.
+func (c *SlowOpCollector) Start(interval time.Duration) {
+	c.wg.Add(1)
+	go c.collectLoop(interval)
+}
+
+// Stop stops scraping.
+func (c *SlowOpCollector) Stop() {
+	close(c.stopCh)
+	c.wg.Wait()
+}
+
+func (c *SlowOpCollector) collectLoop(interval time.Duration) {
+	defer c.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	c.collect()
+	for {
+		select {
+		case <-ticker.C:
+			c.collect()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+func (c *SlowOpCollector) collect() {
+	if c.client == nil {
+		return
+	}
+
+	c.mu.Lock()
+	stale := c.lastLabels
+	c.lastLabels = nil
+	c.mu.Unlock()
+	for _, labels := range stale {
+		mongodbCurrentOpQueryUptime.DeleteLabelValues(labels...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := c.client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "currentOp", Value: true},
+		{Key: "active", Value: true},
+		{Key: "microsecs_running", Value: bson.D{{Key: "$gte", Value: c.threshold.Microseconds()}}},
+	})
+
+	var decoded bson.M
+	if err := result.Decode(&decoded); err != nil {
+		mongodbCurrentOpParseErrors.WithLabelValues(c.appName).Inc()
+		return
+	}
+
+	inprog, _ := decoded["inprog"].(bson.A)
+	published := make([][]string, 0, len(inprog))
+
+	for _, entry := range inprog {
+		doc, ok := entry.(bson.M)
+		if !ok {
+			mongodbCurrentOpParseErrors.WithLabelValues(c.appName).Inc()
+			continue
+		}
+
+		micros, ok := asInt64(doc["microsecs_running"])
+		if !ok {
+			mongodbCurrentOpParseErrors.WithLabelValues(c.appName).Inc()
+			continue
+		}
+
+		database, collection := splitNamespace(asString(doc["ns"]))
+		labels := []string{
+			c.appName,
+			database,
+			collection,
+			asString(doc["ns"]),
+			asString(doc["op"]),
+			asString(doc["desc"]),
+			fmt.Sprint(doc["opid"]),
+		}
+
+		mongodbCurrentOpQueryUptime.WithLabelValues(labels...).Set(float64(micros) / 1e6)
+		published = append(published, labels)
+
+		if c.logger != nil {
+			c.logger.Warn("mongodb slow operation in progress",
+				"application", c.appName, "database", database, "collection", collection,
+				"operation", asString(doc["op"]), "duration_ms", micros/1000, "opid", fmt.Sprint(doc["opid"]))
+		}
+	}
+
+	c.mu.Lock()
+	c.lastLabels = published
+	c.mu.Unlock()
+}
+
+// splitNamespace splits a MongoDB "database.collection" namespace in two.
+func splitNamespace(ns string) (database, collection string) {
+	database, collection, ok := strings.Cut(ns, ".")
+	if !ok {
+		return ns, ""
+	}
+	return database, collection
+}
+
+func asString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func asInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import "testing"
+
+func TestSplitNamespace(t *testing.T) {
+	cases := []struct {
+		ns             string
+		wantDatabase   string
+		wantCollection string
+	}{
+		{"go_webapi_db.users", "go_webapi_db", "users"},
+		{"admin.$cmd", "admin", "$cmd"},
+		{"no-dot-here", "no-dot-here", ""},
+	}
+
+	for _, tc := range cases {
+		database, collection := splitNamespace(tc.ns)
+		if database != tc.wantDatabase || collection != tc.wantCollection {
+			t.Errorf("splitNamespace(%q) = (%q, %q), want (%q, %q)", tc.ns, database, collection, tc.wantDatabase, tc.wantCollection)
+		}
+	}
+}
+
+func TestAsInt64(t *testing.T) {
+	cases := []struct {
+		in      interface{}
+		want    int64
+		wantOK  bool
+	}{
+		{int32(42), 42, true},
+		{int64(42), 42, true},
+		{float64(42.7), 42, true},
+		{"not a number", 0, false},
+		{nil, 0, false},
+	}
+
+	for _, tc := range cases {
+		got, ok := asInt64(tc.in)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("asInt64(%v) = (%d, %v), want (%d, %v)", tc.in, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestSlowOpCollector_NilClientCollectIsNoop(t *testing.T) {
+	c := NewSlowOpCollector(nil, "go-webapi-db", 0)
+	c.collect() // must not panic
+}
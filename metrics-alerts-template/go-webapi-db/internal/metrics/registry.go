@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Registry owns the RED (rate/errors/duration) metrics for HTTP traffic and
+// registers them against an injected prometheus.Registerer, so callers can
+// scope it to a custom registry instead of the global default.
+type Registry struct {
+	requestsTotal    *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	requestsInFlight prometheus.Gauge
+}
+
+// NewRegistry builds a Registry and registers its collectors against reg.
+// namespace is prefixed to every metric name (e.g. "go_webapi_db") and may
+// be empty.
+func NewRegistry(reg prometheus.Registerer, namespace string) *Registry {
+	r := &Registry{
+		requestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "http_requests_total",
+				Help:      "Total number of HTTP requests.",
+			},
+			[]string{"method", "path", "status"},
+		),
+		requestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "http_request_duration_seconds",
+				Help:      "HTTP request duration in seconds.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"method", "path"},
+		),
+		requestsInFlight: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "http_requests_in_flight",
+				Help:      "Number of HTTP requests currently being served.",
+			},
+		),
+	}
+
+	reg.MustRegister(r.requestsTotal, r.requestDuration, r.requestsInFlight)
+	return r
+}
+
+// Middleware returns net/http middleware that records RED metrics for next.
+// routeTemplate should return a low-cardinality route pattern for a request
+// (e.g. "/api/users/{id}") rather than the raw, parameterized URL path.
+func (r *Registry) Middleware(routeTemplate func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.requestsInFlight.Inc()
+		defer r.requestsInFlight.Dec()
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, req)
+
+		path := routeTemplate(req)
+		r.requestsTotal.WithLabelValues(req.Method, path, strconv.Itoa(rec.status)).Inc()
+		r.requestDuration.WithLabelValues(req.Method, path).Observe(time.Since(start).Seconds())
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
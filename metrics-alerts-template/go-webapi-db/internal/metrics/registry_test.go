@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegistry_MiddlewareRecordsRED(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	r := NewRegistry(reg, "")
+
+	handler := r.Middleware(func(*http.Request) string {
+		return "/api/users/{id}"
+	}, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/123", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error: %v", err)
+	}
+
+	var found bool
+	for _, mf := range metricFamilies {
+		if mf.GetName() == "http_requests_total" {
+			found = true
+			for _, m := range mf.GetMetric() {
+				for _, label := range m.GetLabel() {
+					if label.GetName() == "path" && label.GetValue() != "/api/users/{id}" {
+						t.Errorf("expected path label '/api/users/{id}', got %q", label.GetValue())
+					}
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("expected http_requests_total to be registered")
+	}
+}
+
+func TestNewRegistry_Namespaced(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	NewRegistry(reg, "go_webapi_db")
+
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error: %v", err)
+	}
+
+	var found bool
+	for _, mf := range metricFamilies {
+		if mf.GetName() == "go_webapi_db_http_requests_in_flight" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected namespaced metric 'go_webapi_db_http_requests_in_flight' to be registered")
+	}
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// LabelPairSorter sorts a metric's label pairs by name. prometheus.Registry
+// guarantees this ordering within a single Gather call, but
+// TransactionalGatherer reuses one snapshot across many scrapes, so it
+// re-applies the same ordering itself whenever it refreshes that snapshot.
+type LabelPairSorter []*dto.LabelPair
+
+func (s LabelPairSorter) Len() int      { return len(s) }
+func (s LabelPairSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s LabelPairSorter) Less(i, j int) bool {
+	return s[i].GetName() < s[j].GetName()
+}
+
+// TransactionalGatherer wraps a prometheus.Gatherer and caches its most
+// recent Gather() snapshot, so many concurrent /metrics scrapes under high
+// scrape frequency share one collection pass instead of each re-walking
+// every registered Collector. It implements prometheus.Gatherer itself, so
+// it can be handed straight to promhttp.HandlerFor.
+type TransactionalGatherer struct {
+	source prometheus.Gatherer
+
+	mu         sync.RWMutex
+	cached     []*dto.MetricFamily
+	generation uint64
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewTransactionalGatherer wraps source, eagerly collecting one snapshot so
+// the first Gather call never blocks on a slow Collector.
+func NewTransactionalGatherer(source prometheus.Gatherer) *TransactionalGatherer {
+	g := &TransactionalGatherer{source: source, stopCh: make(chan struct{})}
+	g.Refresh()
+	return g
+}
+
+// Start begins refreshing the cached snapshot every interval until Stop is
+// called.
+func (g *TransactionalGatherer) Start(interval time.Duration) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				g.Refresh()
+			case <-g.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the periodic refresh loop started by Start.
+func (g *TransactionalGatherer) Stop() {
+	close(g.stopCh)
+	g.wg.Wait()
+}
+
+// Refresh collects a fresh snapshot from source and atomically swaps it in.
+// Callers on a write path that want the very next scrape to reflect a
+// just-recorded value (rather than waiting for the next Start tick) can
+// call this directly.
+func (g *TransactionalGatherer) Refresh() error {
+	families, err := g.source.Gather()
+	if err != nil {
+		return err
+	}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			sort.Sort(LabelPairSorter(m.GetLabel()))
+		}
+	}
+
+	g.mu.Lock()
+	g.cached = families
+	g.generation++
+	g.mu.Unlock()
+	return nil
+}
+
+// Generation returns how many times Refresh has swapped in a new snapshot,
+// so tests can assert a refresh actually happened without racing on the
+// snapshot contents themselves.
+func (g *TransactionalGatherer) Generation() uint64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.generation
+}
+
+// Gather implements prometheus.Gatherer by returning the cached snapshot.
+func (g *TransactionalGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.cached, nil
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTransactionalGatherer_CachesSnapshotUntilRefresh(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "requests_total"})
+	reg.MustRegister(counter)
+
+	g := NewTransactionalGatherer(reg)
+	if got := g.Generation(); got != 1 {
+		t.Fatalf("expected generation 1 after construction, got %d", got)
+	}
+
+	counter.Inc()
+
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error: %v", err)
+	}
+	if got := families[0].GetMetric()[0].GetCounter().GetValue(); got != 0 {
+		t.Errorf("expected cached snapshot to still read 0 before Refresh, got %v", got)
+	}
+
+	if err := g.Refresh(); err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if got := g.Generation(); got != 2 {
+		t.Errorf("expected generation 2 after Refresh, got %d", got)
+	}
+
+	families, _ = g.Gather()
+	if got := families[0].GetMetric()[0].GetCounter().GetValue(); got != 1 {
+		t.Errorf("expected refreshed snapshot to read 1, got %v", got)
+	}
+}
+
+func TestTransactionalGatherer_SortsLabelPairsOnRefresh(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "pool_size"}, []string{"zzz", "aaa"})
+	reg.MustRegister(gauge)
+	gauge.WithLabelValues("z-value", "a-value").Set(1)
+
+	g := NewTransactionalGatherer(reg)
+
+	families, _ := g.Gather()
+	labels := families[0].GetMetric()[0].GetLabel()
+	for i := 1; i < len(labels); i++ {
+		if labels[i-1].GetName() > labels[i].GetName() {
+			t.Errorf("expected label pairs sorted by name, got %v then %v", labels[i-1].GetName(), labels[i].GetName())
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RequestType distinguishes the kind of operation UsersStat.Update records,
+// so per-user traffic can be broken down without coupling this package to
+// service.MetricsService's operation label values.
+type RequestType string
+
+const (
+	RequestTypeHTTP RequestType = "http"
+)
+
+// userStatOverflowUser is the bucket distinct users collapse into once
+// UsersStatAggregator's max-cardinality cap is reached.
+const userStatOverflowUser = "other"
+
+// UsersStat aggregates per-user/per-resource request and byte counts.
+// Update is called once per request (from middleware.UsersStatMiddleware);
+// implementations must be safe for concurrent use.
+type UsersStat interface {
+	Update(user, resource string, reqType RequestType, in, out uint64)
+}
+
+type userStatKey struct {
+	user     string
+	resource string
+	reqType  RequestType
+}
+
+type userStatCounters struct {
+	requests uint64
+	bytesIn  uint64
+	bytesOut uint64
+}
+
+// UsersStatAggregator is the default UsersStat: an in-memory map of
+// userStatKey -> counters, capped at maxUsers distinct users (first-seen
+// users keep their own series; anything past the cap collapses into the
+// "other" bucket) - the same bounded-cardinality shape as
+// middleware.uriCardinalityGuard and service.boundedLabelSet, reused here
+// so a flood of distinct/spoofed user IDs can't blow up label
+// cardinality. It implements prometheus.Collector itself rather than
+// updating a CounterVec per request, so per-user label combinations are
+// only materialized when something actually scrapes /metrics.
+type UsersStatAggregator struct {
+	maxUsers int
+
+	mu        sync.Mutex
+	seenUsers map[string]struct{}
+	counters  map[userStatKey]*userStatCounters
+
+	requestsDesc *prometheus.Desc
+	bytesInDesc  *prometheus.Desc
+	bytesOutDesc *prometheus.Desc
+}
+
+// NewUsersStatAggregator creates an aggregator that tracks up to maxUsers
+// distinct users before collapsing further ones into the "other" bucket.
+func NewUsersStatAggregator(maxUsers int) *UsersStatAggregator {
+	labels := []string{"user", "resource", "type"}
+	return &UsersStatAggregator{
+		maxUsers:  maxUsers,
+		seenUsers: make(map[string]struct{}),
+		counters:  make(map[userStatKey]*userStatCounters),
+		requestsDesc: prometheus.NewDesc(
+			"user_requests_total", "Total number of requests per user", labels, nil,
+		),
+		bytesInDesc: prometheus.NewDesc(
+			"user_bytes_in_total", "Total inbound request bytes per user", labels, nil,
+		),
+		bytesOutDesc: prometheus.NewDesc(
+			"user_bytes_out_total", "Total outbound response bytes per user", labels, nil,
+		),
+	}
+}
+
+// Update implements UsersStat.
+func (a *UsersStatAggregator) Update(user, resource string, reqType RequestType, in, out uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.seenUsers[user]; !ok {
+		if len(a.seenUsers) >= a.maxUsers {
+			user = userStatOverflowUser
+		} else {
+			a.seenUsers[user] = struct{}{}
+		}
+	}
+
+	key := userStatKey{user: user, resource: resource, reqType: reqType}
+	c, ok := a.counters[key]
+	if !ok {
+		c = &userStatCounters{}
+		a.counters[key] = c
+	}
+	c.requests++
+	c.bytesIn += in
+	c.bytesOut += out
+}
+
+// Describe implements prometheus.Collector. The label set is only known at
+// Collect time (it grows as new users are seen), so Describe intentionally
+// sends no descriptors - the standard "unchecked collector" pattern.
+func (a *UsersStatAggregator) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector, materializing one set of
+// request/bytes-in/bytes-out series per tracked (user, resource, type) at
+// scrape time.
+func (a *UsersStatAggregator) Collect(ch chan<- prometheus.Metric) {
+	for key, c := range a.snapshot() {
+		labels := []string{key.user, key.resource, string(key.reqType)}
+		ch <- prometheus.MustNewConstMetric(a.requestsDesc, prometheus.CounterValue, float64(c.requests), labels...)
+		ch <- prometheus.MustNewConstMetric(a.bytesInDesc, prometheus.CounterValue, float64(c.bytesIn), labels...)
+		ch <- prometheus.MustNewConstMetric(a.bytesOutDesc, prometheus.CounterValue, float64(c.bytesOut), labels...)
+	}
+}
+
+func (a *UsersStatAggregator) snapshot() map[userStatKey]userStatCounters {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	snapshot := make(map[userStatKey]userStatCounters, len(a.counters))
+	for key, c := range a.counters {
+		snapshot[key] = *c
+	}
+	return snapshot
+}
+
+// UserStatSnapshot is one (user, resource, type)'s counters, as reported by
+// the /debug/user-metrics endpoint for operators without Prometheus.
+type UserStatSnapshot struct {
+	User     string `json:"user"`
+	Resource string `json:"resource"`
+	Type     string `json:"type"`
+	Requests uint64 `json:"requests"`
+	BytesIn  uint64 `json:"bytes_in"`
+	BytesOut uint64 `json:"bytes_out"`
+}
+
+// Snapshot returns the aggregator's current counters as plain data, for
+// JSON debug endpoints rather than a Prometheus scrape.
+func (a *UsersStatAggregator) Snapshot() []UserStatSnapshot {
+	out := make([]UserStatSnapshot, 0)
+	for key, c := range a.snapshot() {
+		out = append(out, UserStatSnapshot{
+			User:     key.user,
+			Resource: key.resource,
+			Type:     string(key.reqType),
+			Requests: c.requests,
+			BytesIn:  c.bytesIn,
+			BytesOut: c.bytesOut,
+		})
+	}
+	return out
+}
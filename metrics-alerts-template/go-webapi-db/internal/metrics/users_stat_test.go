@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestUsersStatAggregator_AccumulatesPerUserCounters(t *testing.T) {
+	a := NewUsersStatAggregator(10)
+
+	a.Update("user-1", "/api/users/{id}", RequestTypeHTTP, 10, 20)
+	a.Update("user-1", "/api/users/{id}", RequestTypeHTTP, 5, 15)
+
+	snapshot := a.Snapshot()
+	if len(snapshot) != 1 {
+		t.Fatalf("expected 1 tracked (user, resource, type), got %d", len(snapshot))
+	}
+	if snapshot[0].Requests != 2 || snapshot[0].BytesIn != 15 || snapshot[0].BytesOut != 35 {
+		t.Errorf("unexpected counters: %+v", snapshot[0])
+	}
+}
+
+func TestUsersStatAggregator_OverflowsPastMaxUsers(t *testing.T) {
+	a := NewUsersStatAggregator(1)
+
+	a.Update("user-1", "/r", RequestTypeHTTP, 1, 1)
+	a.Update("user-2", "/r", RequestTypeHTTP, 1, 1)
+
+	var sawOther bool
+	for _, s := range a.Snapshot() {
+		if s.User == userStatOverflowUser {
+			sawOther = true
+		}
+		if s.User == "user-2" {
+			t.Error("user-2 should have overflowed into the \"other\" bucket")
+		}
+	}
+	if !sawOther {
+		t.Error("expected an \"other\" bucket entry for the overflowing user")
+	}
+}
+
+func TestUsersStatAggregator_CollectEmitsPrometheusMetrics(t *testing.T) {
+	a := NewUsersStatAggregator(10)
+	a.Update("user-1", "/r", RequestTypeHTTP, 3, 7)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(a)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	expected := map[string]bool{
+		"user_requests_total": false,
+		"user_bytes_in_total":  false,
+		"user_bytes_out_total": false,
+	}
+	for _, mf := range families {
+		if _, ok := expected[mf.GetName()]; ok {
+			expected[mf.GetName()] = true
+		}
+	}
+	for name, found := range expected {
+		if !found {
+			t.Errorf("expected metric %s not found", name)
+		}
+	}
+}
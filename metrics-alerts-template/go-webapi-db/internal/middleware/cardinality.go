@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"sort"
+	"sync"
+)
+
+// overflowURI is the sentinel label value a (method, uri) pair is relabeled
+// to once uriCardinalityGuard's cap is reached, so scanner/probe traffic
+// hitting random paths can't blow up http_server_* metric series count.
+const overflowURI = "__overflow__"
+
+// uriCardinalityGuard bounds how many distinct (method, uri) pairs may
+// receive a real uri label, same spirit as service.boundedLabelSet for
+// error_type: the first max distinct pairs seen keep their own series,
+// everything after that collapses into overflowURI. It additionally counts
+// observations per pair so the /internal/cardinality debug endpoint can
+// list the current top label combinations by series count.
+type uriCardinalityGuard struct {
+	mu     sync.Mutex
+	max    int
+	counts map[cardinalityKey]int64
+}
+
+type cardinalityKey struct {
+	method string
+	uri    string
+}
+
+func newURICardinalityGuard(max int) *uriCardinalityGuard {
+	return &uriCardinalityGuard{max: max, counts: make(map[cardinalityKey]int64)}
+}
+
+// Resolve returns uri if (method, uri) is already tracked or there's still
+// room to track it, or overflowURI once max distinct pairs have already
+// been seen.
+func (g *uriCardinalityGuard) Resolve(method, uri string) string {
+	key := cardinalityKey{method: method, uri: uri}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.counts[key]; ok {
+		g.counts[key]++
+		return uri
+	}
+	if len(g.counts) >= g.max {
+		g.counts[cardinalityKey{method: method, uri: overflowURI}]++
+		return overflowURI
+	}
+	g.counts[key] = 1
+	return uri
+}
+
+// CardinalityEntry is one (method, uri) pair's observation count, as
+// reported by the /internal/cardinality debug endpoint.
+type CardinalityEntry struct {
+	Method string `json:"method"`
+	URI    string `json:"uri"`
+	Count  int64  `json:"count"`
+}
+
+// TopN returns the guard's tracked pairs ordered by descending observation
+// count, capped at n entries.
+func (g *uriCardinalityGuard) TopN(n int) []CardinalityEntry {
+	g.mu.Lock()
+	entries := make([]CardinalityEntry, 0, len(g.counts))
+	for key, count := range g.counts {
+		entries = append(entries, CardinalityEntry{Method: key.method, URI: key.uri, Count: count})
+	}
+	g.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		if entries[i].Method != entries[j].Method {
+			return entries[i].Method < entries[j].Method
+		}
+		return entries[i].URI < entries[j].URI
+	})
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// defaultCardinalityGuard backs the package-level MetricsMiddleware and
+// NewExporterMetricsMiddleware, which don't otherwise have a shared place
+// to carry per-process state. CardinalityTopN reports on it for the
+// /internal/cardinality debug endpoint.
+var defaultCardinalityGuard = newURICardinalityGuard(500)
+
+// SetURICardinalityLimit reconfigures the shared cardinality guard's cap,
+// for wiring METRICS_MAX_URI_CARDINALITY at startup.
+func SetURICardinalityLimit(max int) {
+	defaultCardinalityGuard = newURICardinalityGuard(max)
+}
+
+// CardinalityTopN reports the shared guard's top n (method, uri) pairs by
+// observation count, for the /internal/cardinality debug endpoint.
+func CardinalityTopN(n int) []CardinalityEntry {
+	return defaultCardinalityGuard.TopN(n)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import "testing"
+
+func TestURICardinalityGuard_TracksUpToCap(t *testing.T) {
+	g := newURICardinalityGuard(2)
+
+	if got := g.Resolve("GET", "/a"); got != "/a" {
+		t.Errorf("Resolve(/a) = %s, want /a", got)
+	}
+	if got := g.Resolve("GET", "/b"); got != "/b" {
+		t.Errorf("Resolve(/b) = %s, want /b", got)
+	}
+	if got := g.Resolve("GET", "/a"); got != "/a" {
+		t.Errorf("repeated Resolve(/a) = %s, want /a", got)
+	}
+}
+
+func TestURICardinalityGuard_OverflowsPastCap(t *testing.T) {
+	g := newURICardinalityGuard(1)
+
+	g.Resolve("GET", "/a")
+	if got := g.Resolve("GET", "/b"); got != overflowURI {
+		t.Errorf("Resolve(/b) over cap = %s, want %s", got, overflowURI)
+	}
+}
+
+func TestURICardinalityGuard_TopNOrdersByCount(t *testing.T) {
+	g := newURICardinalityGuard(10)
+
+	g.Resolve("GET", "/a")
+	g.Resolve("GET", "/a")
+	g.Resolve("GET", "/a")
+	g.Resolve("GET", "/b")
+
+	top := g.TopN(1)
+	if len(top) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(top))
+	}
+	if top[0].URI != "/a" || top[0].Count != 3 {
+		t.Errorf("expected /a with count 3, got %+v", top[0])
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -8,6 +10,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"go-webapi-db/internal/metrics"
 )
 
 var (
@@ -43,23 +49,40 @@ var (
 		},
 		[]string{"method", "uri", "status"},
 	)
+
+	httpServerPanics = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_server_panics_total",
+			Help: "Total number of HTTP handler panics recovered by RecoveryMiddleware",
+		},
+		[]string{"method", "uri"},
+	)
 )
 
+// MetricsMiddleware is the process-global RED-metrics collector, backed by
+// the package-level promauto vars above (bound to
+// prometheus.DefaultRegisterer) and sanitizeURI's hardcoded
+// legacyPatternURI fallback.
+//
+// Deprecated: use NewMetricsMiddleware with a *RouteRegistry and an
+// explicit prometheus.Registerer instead. It resolves uri labels against
+// routes actually registered at startup (falling back to the literal
+// "other" rather than echoing unmatched paths), and scopes its collectors
+// to reg instead of the global default registry.
 func MetricsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		path := c.Request.URL.Path
 
 		c.Next()
 
 		duration := time.Since(start).Seconds()
 		status := strconv.Itoa(c.Writer.Status())
 		method := c.Request.Method
-		uri := sanitizeURI(path)
+		uri := defaultCardinalityGuard.Resolve(method, sanitizeURI(c))
 
 		// Record metrics
 		httpRequestsTotal.WithLabelValues(method, uri, status).Inc()
-		httpRequestDuration.WithLabelValues(method, uri, status).Observe(duration)
+		observeWithExemplar(httpRequestDuration.WithLabelValues(method, uri, status), duration, exemplarLabelsFromRequest(c.Request))
 
 		// Record error metrics
 		statusCode := c.Writer.Status()
@@ -68,11 +91,244 @@ func MetricsMiddleware() gin.HandlerFunc {
 		} else if statusCode >= 400 {
 			httpClientErrors.WithLabelValues(method, uri, status).Inc()
 		}
+
+		annotateSpan(c.Request.Context(), method, uri, statusCode)
+	}
+}
+
+// metricsCollectors holds the RED-metric collectors for a single
+// prometheus.Registerer, mirroring the package-level promauto vars above
+// but scoped to an explicit registry instead of the global default - the
+// same pattern metrics.MongoMetrics uses for MongoDB's collectors.
+type metricsCollectors struct {
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	serverErrors    *prometheus.CounterVec
+	clientErrors    *prometheus.CounterVec
+}
+
+func newMetricsCollectors(reg prometheus.Registerer) *metricsCollectors {
+	factory := promauto.With(reg)
+	return &metricsCollectors{
+		requestsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_server_requests_total",
+				Help: "Total number of HTTP requests",
+			},
+			[]string{"method", "uri", "status"},
+		),
+		requestDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_server_requests_seconds",
+				Help:    "HTTP request duration in seconds",
+				Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			},
+			[]string{"method", "uri", "status"},
+		),
+		serverErrors: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_server_errors_total",
+				Help: "Total number of HTTP server errors (5xx)",
+			},
+			[]string{"method", "uri", "status"},
+		),
+		clientErrors: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_server_client_errors_total",
+				Help: "Total number of HTTP client errors (4xx)",
+			},
+			[]string{"method", "uri", "status"},
+		),
+	}
+}
+
+// NewMetricsMiddleware is the registry-scoped replacement for
+// MetricsMiddleware: uri labels come from resolving the request path
+// against routes instead of sanitizeURI's hardcoded legacyPatternURI
+// fallback, and its collectors register against reg instead of
+// prometheus.DefaultRegisterer, so it can share a dedicated registry with
+// MongoMetrics/DatastoreCollector instead of the global default.
+func NewMetricsMiddleware(routes *RouteRegistry, reg prometheus.Registerer) gin.HandlerFunc {
+	collectors := newMetricsCollectors(reg)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		duration := time.Since(start).Seconds()
+		statusCode := c.Writer.Status()
+		status := strconv.Itoa(statusCode)
+		method := c.Request.Method
+		uri := defaultCardinalityGuard.Resolve(method, resolveURI(c, routes))
+
+		collectors.requestsTotal.WithLabelValues(method, uri, status).Inc()
+		observeWithExemplar(collectors.requestDuration.WithLabelValues(method, uri, status), duration, exemplarLabelsFromRequest(c.Request))
+
+		if statusCode >= 500 {
+			collectors.serverErrors.WithLabelValues(method, uri, status).Inc()
+		} else if statusCode >= 400 {
+			collectors.clientErrors.WithLabelValues(method, uri, status).Inc()
+		}
+
+		annotateSpan(c.Request.Context(), method, uri, statusCode)
+	}
+}
+
+// resolveURI returns the route template matching c's request, preferring
+// Gin's own FullPath() (set whenever a route actually matched - the
+// cheapest and most authoritative source) and falling back to routes for
+// anything that matched no route, e.g. 404s and scanner traffic.
+func resolveURI(c *gin.Context, routes *RouteRegistry) string {
+	if fullPath := c.FullPath(); fullPath != "" {
+		return ginRouteToLabel(fullPath)
+	}
+	return routes.Resolve(c.Request.URL.Path)
+}
+
+// annotateSpan attaches the standard HTTP semantic-convention attributes to
+// the active OpenTelemetry span (started by trace.GinMiddleware upstream),
+// using the same method/uri label values MetricsMiddleware already resolved
+// so the span's http.route matches what the RED metrics were recorded
+// under. A no-op when ctx carries no recording span.
+func annotateSpan(ctx context.Context, method, uri string, statusCode int) {
+	span := oteltrace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		attribute.String("http.method", method),
+		attribute.String("http.route", uri),
+		attribute.Int("http.status_code", statusCode),
+	)
+}
+
+// NewExporterMetricsMiddleware is the exporter-backed equivalent of
+// MetricsMiddleware, for use when cfg.Metrics.Exporter selects a non-default
+// backend (DogStatsD, OTLP, or multi) instead of the package-level
+// promauto collectors above.
+func NewExporterMetricsMiddleware(exporter metrics.Exporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		duration := time.Since(start).Seconds()
+		status := strconv.Itoa(c.Writer.Status())
+		method := c.Request.Method
+		uri := defaultCardinalityGuard.Resolve(method, sanitizeURI(c))
+
+		exporter.IncrCounter("http_server_requests_total", 1, "method", method, "uri", uri, "status", status)
+		if exemplarLabels := exemplarLabelsFromRequest(c.Request); exemplarLabels != nil {
+			if observer, ok := exporter.(metrics.ExemplarObserver); ok {
+				observer.ObserveHistogramWithExemplar("http_server_requests_seconds", duration, exemplarLabels, "method", method, "uri", uri, "status", status)
+			} else {
+				exporter.ObserveHistogram("http_server_requests_seconds", duration, "method", method, "uri", uri, "status", status)
+			}
+		} else {
+			exporter.ObserveHistogram("http_server_requests_seconds", duration, "method", method, "uri", uri, "status", status)
+		}
+
+		statusCode := c.Writer.Status()
+		if statusCode >= 500 {
+			exporter.IncrCounter("http_server_errors_total", 1, "method", method, "uri", uri, "status", status)
+		} else if statusCode >= 400 {
+			exporter.IncrCounter("http_server_client_errors_total", 1, "method", method, "uri", uri, "status", status)
+		}
+	}
+}
+
+// UsersStatMiddleware records per-user/per-resource request and byte
+// traffic into stat, alongside whichever of MetricsMiddleware/
+// NewExporterMetricsMiddleware is handling aggregate RED metrics - this
+// covers a different signal (per-user traffic) that neither of those
+// produces. This service has no authentication layer yet, so the X-User-ID
+// request header is the closest thing to an authenticated user id;
+// requests without it are tracked under "anonymous". in/out bytes come
+// from the request's and response's Content-Length.
+func UsersStatMiddleware(stat metrics.UsersStat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var in uint64
+		if c.Request.ContentLength > 0 {
+			in = uint64(c.Request.ContentLength)
+		}
+
+		c.Next()
+
+		user := c.GetHeader("X-User-ID")
+		if user == "" {
+			user = "anonymous"
+		}
+		resource := sanitizeURI(c)
+
+		var out uint64
+		if cl := c.Writer.Header().Get("Content-Length"); cl != "" {
+			if n, err := strconv.ParseUint(cl, 10, 64); err == nil {
+				out = n
+			}
+		}
+
+		stat.Update(user, resource, metrics.RequestTypeHTTP, in, out)
+	}
+}
+
+// exemplarLabelsFromRequest returns a trace_id/span_id exemplar label set
+// for req's active OpenTelemetry span (attached by trace.GinMiddleware
+// upstream), or nil if req carries no valid span context.
+func exemplarLabelsFromRequest(req *http.Request) map[string]string {
+	spanCtx := oteltrace.SpanContextFromContext(req.Context())
+	if !spanCtx.IsValid() {
+		return nil
+	}
+	return map[string]string{
+		"trace_id": spanCtx.TraceID().String(),
+		"span_id":  spanCtx.SpanID().String(),
+	}
+}
+
+// observeWithExemplar observes value on observer, attaching exemplarLabels
+// when non-nil and the observer supports exemplars (every
+// prometheus.HistogramVec observer does).
+func observeWithExemplar(observer prometheus.Observer, value float64, exemplarLabels map[string]string) {
+	if exemplarLabels != nil {
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+			exemplarObserver.ObserveWithExemplar(value, exemplarLabels)
+			return
+		}
+	}
+	observer.Observe(value)
+}
+
+// sanitizeURI normalizes a request's path into a low-cardinality label
+// value. When Gin matched a registered route, c.FullPath() returns that
+// route's template (e.g. "/api/users/:id"), which ginRouteToLabel converts
+// directly into "/api/users/{id}" - this replaces the old hand-rolled
+// path patterns below, which had to be updated by hand for every new
+// route. For requests that matched no route (c.FullPath() == "", most
+// commonly 404s from scanner/probe traffic), legacyPatternURI provides a
+// best-effort fallback so those paths still collapse sensibly.
+func sanitizeURI(c *gin.Context) string {
+	if fullPath := c.FullPath(); fullPath != "" {
+		return ginRouteToLabel(fullPath)
+	}
+	return legacyPatternURI(c.Request.URL.Path)
+}
+
+// ginRouteToLabel converts a Gin route template's :param and *param
+// segments into {param} placeholders.
+func ginRouteToLabel(route string) string {
+	segments := strings.Split(route, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			segments[i] = "{" + seg[1:] + "}"
+		}
 	}
+	return strings.Join(segments, "/")
 }
 
-func sanitizeURI(uri string) string {
-	// Replace path variables with placeholders for better metric aggregation
+// legacyPatternURI is the fallback pattern registry used when a request
+// matched no Gin route, so there's no route template to fall back on.
+func legacyPatternURI(uri string) string {
 	if strings.HasPrefix(uri, "/api/users/") {
 		parts := strings.Split(strings.TrimPrefix(uri, "/api/users/"), "/")
 		if len(parts) > 0 {
@@ -2,16 +2,30 @@ package middleware
 
 import (
 	"net/http"
+	"runtime/debug"
 
 	"github.com/gin-gonic/gin"
+
+	"go-webapi-db/internal/logger"
 )
 
+// RecoveryMiddleware recovers a panicking handler, logs the panic value and
+// a stack trace via the request-scoped logger (which auto-attaches the
+// request's trace ID, if any, the same way every other log record does),
+// increments http_server_panics_total, and responds 500 instead of letting
+// the panic crash the server.
 func RecoveryMiddleware() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		method := c.Request.Method
+		uri := defaultCardinalityGuard.Resolve(method, sanitizeURI(c))
+		httpServerPanics.WithLabelValues(method, uri).Inc()
+
+		logger.FromContext(c.Request.Context()).Error("panic recovered",
+			"method", method, "uri", uri, "panic", recovered, "stack", string(debug.Stack()))
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
 			"error": "Internal server error",
 		})
-		c.AbortWithStatus(http.StatusInternalServerError)
 	})
 }
 
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRecoveryMiddleware_RecoversAndRecordsPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RecoveryMiddleware())
+	r.GET("/test/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest("GET", "/test/panic", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	// http_server_panics_total is a package-level promauto counter, so it
+	// lives in the default registry.
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	var panicsTotal float64
+	for _, mf := range families {
+		if mf.GetName() == "http_server_panics_total" {
+			for _, m := range mf.GetMetric() {
+				panicsTotal += m.GetCounter().GetValue()
+			}
+		}
+	}
+	if panicsTotal != 1 {
+		t.Errorf("http_server_panics_total = %v, want 1", panicsTotal)
+	}
+}
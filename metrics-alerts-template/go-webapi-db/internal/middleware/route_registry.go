@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routePattern is a single registered route template, pre-split into path
+// segments so Resolve doesn't re-split it on every request.
+type routePattern struct {
+	segments []string
+	label    string
+}
+
+// RouteRegistry matches request paths against a fixed set of route
+// templates (e.g. "/api/users/:id", "/api/orders/:id/items/:itemId")
+// collected at server startup, returning the matching template's label form
+// (e.g. "/api/users/{id}"). Paths that match no registered route resolve to
+// the literal "other", so 404s, scanner probes, and anything else outside
+// the known route set can never explode metric cardinality the way echoing
+// the raw path would.
+//
+// A RouteRegistry is meant to be built once at startup (see
+// RegisterGinRoutes) and then only read from; it is not safe to mutate
+// concurrently with Resolve.
+type RouteRegistry struct {
+	patterns []routePattern
+}
+
+// NewRouteRegistry returns an empty RouteRegistry. Use Register or
+// RegisterGinRoutes to populate it before serving traffic.
+func NewRouteRegistry() *RouteRegistry {
+	return &RouteRegistry{}
+}
+
+// Register adds a single route template to the registry.
+func (rr *RouteRegistry) Register(pattern string) {
+	rr.patterns = append(rr.patterns, routePattern{
+		segments: splitPath(pattern),
+		label:    ginRouteToLabel(pattern),
+	})
+}
+
+// RegisterGinRoutes registers every route in routes, typically called with
+// router.Routes() once a gin.Engine has had all of its routes added.
+func (rr *RouteRegistry) RegisterGinRoutes(routes gin.RoutesInfo) {
+	for _, route := range routes {
+		rr.Register(route.Path)
+	}
+}
+
+// Resolve returns the registered route template matching path, with :param
+// and *param segments rendered as "{param}", or "other" if no registered
+// route matches.
+func (rr *RouteRegistry) Resolve(path string) string {
+	requestSegments := splitPath(path)
+	for _, p := range rr.patterns {
+		if segmentsMatch(p.segments, requestSegments) {
+			return p.label
+		}
+	}
+	return "other"
+}
+
+func splitPath(path string) []string {
+	return strings.Split(path, "/")
+}
+
+// segmentsMatch reports whether requestSegments satisfies pattern,
+// treating a ":name" segment as matching any single segment and a
+// "*name" segment as matching the remainder of the path.
+func segmentsMatch(pattern, requestSegments []string) bool {
+	for i, seg := range pattern {
+		if strings.HasPrefix(seg, "*") {
+			return true
+		}
+		if i >= len(requestSegments) {
+			return false
+		}
+		if !strings.HasPrefix(seg, ":") && seg != requestSegments[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(requestSegments)
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"time"
 
-	"go-webapi-db/internal/model"
 	"go-webapi-db/internal/metrics"
+	"go-webapi-db/internal/model"
+	"go-webapi-db/internal/trace"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 const (
 	appName  = "go-webapi-db"
 	database = "go_webapi_db"
+	dbSystem = "mongodb"
 )
 
 // InstrumentedUserRepository wraps UserRepository with metrics collection
@@ -29,92 +35,166 @@ func NewInstrumentedUserRepository(repo *UserRepository) *InstrumentedUserReposi
 var _ UserRepositoryInterface = (*InstrumentedUserRepository)(nil)
 
 func (r *InstrumentedUserRepository) Create(ctx context.Context, user *model.User) error {
+	ctx, span := startDBSpan(ctx, "insert")
+
 	start := time.Now()
 	err := r.repo.Create(ctx, user)
 	duration := time.Since(start)
-	
-	metrics.RecordOperation(appName, database, "insert", "users", duration, err)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "insert", "users", duration, err)
+	endDBSpan(span, err)
 	return err
 }
 
 func (r *InstrumentedUserRepository) FindByID(ctx context.Context, id string) (*model.User, error) {
+	ctx, span := startDBSpan(ctx, "find")
+
 	start := time.Now()
 	user, err := r.repo.FindByID(ctx, id)
 	duration := time.Since(start)
-	
-	metrics.RecordOperation(appName, database, "find", "users", duration, err)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "find", "users", duration, err)
+	endDBSpan(span, err)
 	return user, err
 }
 
 func (r *InstrumentedUserRepository) FindAll(ctx context.Context) ([]*model.User, error) {
+	ctx, span := startDBSpan(ctx, "find")
+
 	start := time.Now()
 	users, err := r.repo.FindAll(ctx)
 	duration := time.Since(start)
-	
-	metrics.RecordOperation(appName, database, "find", "users", duration, err)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "find", "users", duration, err)
+	endDBSpan(span, err)
 	return users, err
 }
 
 func (r *InstrumentedUserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	ctx, span := startDBSpan(ctx, "find")
+
 	start := time.Now()
 	user, err := r.repo.FindByEmail(ctx, email)
 	duration := time.Since(start)
-	
-	metrics.RecordOperation(appName, database, "find", "users", duration, err)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "find", "users", duration, err)
+	endDBSpan(span, err)
 	return user, err
 }
 
 func (r *InstrumentedUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	ctx, span := startDBSpan(ctx, "count")
+
 	start := time.Now()
 	exists, err := r.repo.ExistsByEmail(ctx, email)
 	duration := time.Since(start)
-	
-	metrics.RecordOperation(appName, database, "count", "users", duration, err)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "count", "users", duration, err)
+	endDBSpan(span, err)
 	return exists, err
 }
 
 func (r *InstrumentedUserRepository) Update(ctx context.Context, id string, user *model.User) error {
+	ctx, span := startDBSpan(ctx, "update")
+
 	start := time.Now()
 	err := r.repo.Update(ctx, id, user)
 	duration := time.Since(start)
-	
-	metrics.RecordOperation(appName, database, "update", "users", duration, err)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "update", "users", duration, err)
+	endDBSpan(span, err)
 	return err
 }
 
 func (r *InstrumentedUserRepository) Delete(ctx context.Context, id string) error {
+	ctx, span := startDBSpan(ctx, "delete")
+
 	start := time.Now()
 	err := r.repo.Delete(ctx, id)
 	duration := time.Since(start)
-	
-	metrics.RecordOperation(appName, database, "delete", "users", duration, err)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "delete", "users", duration, err)
+	endDBSpan(span, err)
 	return err
 }
 
 func (r *InstrumentedUserRepository) FindByStatus(ctx context.Context, status string) ([]*model.User, error) {
+	ctx, span := startDBSpan(ctx, "find")
+
 	start := time.Now()
 	users, err := r.repo.FindByStatus(ctx, status)
 	duration := time.Since(start)
-	
-	metrics.RecordOperation(appName, database, "find", "users", duration, err)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "find", "users", duration, err)
+	endDBSpan(span, err)
 	return users, err
 }
 
 func (r *InstrumentedUserRepository) CountByStatus(ctx context.Context, status string) (int64, error) {
+	ctx, span := startDBSpan(ctx, "count")
+
 	start := time.Now()
 	count, err := r.repo.CountByStatus(ctx, status)
 	duration := time.Since(start)
-	
-	metrics.RecordOperation(appName, database, "count", "users", duration, err)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "count", "users", duration, err)
+	endDBSpan(span, err)
 	return count, err
 }
 
 func (r *InstrumentedUserRepository) ExistsByID(ctx context.Context, id string) (bool, error) {
+	ctx, span := startDBSpan(ctx, "count")
+
 	start := time.Now()
 	exists, err := r.repo.ExistsByID(ctx, id)
 	duration := time.Since(start)
-	
-	metrics.RecordOperation(appName, database, "count", "users", duration, err)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "count", "users", duration, err)
+	endDBSpan(span, err)
 	return exists, err
 }
 
+func (r *InstrumentedUserRepository) List(ctx context.Context, opts ListOptions) (*PageResult[*model.User], error) {
+	ctx, span := startDBSpan(ctx, "aggregate")
+
+	start := time.Now()
+	result, err := r.repo.List(ctx, opts)
+	duration := time.Since(start)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "aggregate", "users", duration, err)
+	endDBSpan(span, err)
+	return result, err
+}
+
+func (r *InstrumentedUserRepository) ListAfter(ctx context.Context, lastID string, limit int) ([]*model.User, error) {
+	ctx, span := startDBSpan(ctx, "find")
+
+	start := time.Now()
+	users, err := r.repo.ListAfter(ctx, lastID, limit)
+	duration := time.Since(start)
+
+	metrics.RecordOperationCtx(ctx, appName, database, "find", "users", duration, err)
+	endDBSpan(span, err)
+	return users, err
+}
+
+// startDBSpan starts a child span named db.users.<op> carrying the
+// semantic-convention-style db.* attributes, mirroring the user.<op> spans
+// UserService starts one layer up.
+func startDBSpan(ctx context.Context, op string) (context.Context, oteltrace.Span) {
+	ctx, span := trace.Tracer().Start(ctx, "db.users."+op)
+	span.SetAttributes(
+		attribute.String("db.system", dbSystem),
+		attribute.String("db.operation", op),
+		attribute.String("db.collection", "users"),
+	)
+	return ctx, span
+}
+
+// endDBSpan records err (if any) as the span's status and ends it.
+func endDBSpan(span oteltrace.Span, err error) {
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
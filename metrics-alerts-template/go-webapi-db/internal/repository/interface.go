@@ -18,5 +18,6 @@ type UserRepositoryInterface interface {
 	FindByStatus(ctx context.Context, status string) ([]*model.User, error)
 	CountByStatus(ctx context.Context, status string) (int64, error)
 	ExistsByID(ctx context.Context, id string) (bool, error)
+	List(ctx context.Context, opts ListOptions) (*PageResult[*model.User], error)
+	ListAfter(ctx context.Context, lastID string, limit int) ([]*model.User, error)
 }
-
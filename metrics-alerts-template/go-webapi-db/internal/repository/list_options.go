@@ -0,0 +1,52 @@
+package repository
+
+import "go.mongodb.org/mongo-driver/bson"
+
+const (
+	// DefaultPageSize is used when ListOptions.PageSize is unset.
+	DefaultPageSize = 20
+	// MaxPageSize bounds ListOptions.PageSize regardless of what's requested.
+	MaxPageSize = 100
+)
+
+// ListOptions controls pagination, sorting, projection, and filtering for
+// List.
+type ListOptions struct {
+	// Page is 1-indexed; values below 1 are treated as 1.
+	Page int
+	// PageSize is clamped to (0, MaxPageSize]; zero defaults to DefaultPageSize.
+	PageSize int
+	// SortBy is the field to sort by; empty defaults to "_id".
+	SortBy string
+	// SortDesc sorts descending when true, ascending otherwise.
+	SortDesc bool
+	// Fields, if non-empty, projects the result to just these fields.
+	Fields []string
+	// Filter is an arbitrary Mongo query predicate; nil matches everything.
+	Filter bson.M
+}
+
+func (o ListOptions) withDefaults() ListOptions {
+	if o.Page < 1 {
+		o.Page = 1
+	}
+	if o.PageSize <= 0 {
+		o.PageSize = DefaultPageSize
+	}
+	if o.PageSize > MaxPageSize {
+		o.PageSize = MaxPageSize
+	}
+	if o.SortBy == "" {
+		o.SortBy = "_id"
+	}
+	return o
+}
+
+// PageResult is a single page of T, along with enough context to render
+// pagination controls.
+type PageResult[T any] struct {
+	Items      []T
+	TotalCount int64
+	Page       int
+	HasNext    bool
+}
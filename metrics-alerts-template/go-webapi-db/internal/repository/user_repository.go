@@ -6,9 +6,11 @@ import (
 	"time"
 
 	"go-webapi-db/internal/model"
+	"go-webapi-db/internal/retry"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository struct {
@@ -25,16 +27,18 @@ func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	result, err := r.collection.InsertOne(ctx, user)
-	if err != nil {
-		return err
-	}
+	return retry.DoWithPolicy(ctx, retry.DefaultPolicy(), func() error {
+		result, err := r.collection.InsertOne(ctx, user)
+		if err != nil {
+			return err
+		}
 
-	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
-		user.ID = oid
-	}
+		if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+			user.ID = oid
+		}
 
-	return nil
+		return nil
+	}, retry.MongoClassifier)
 }
 
 func (r *UserRepository) FindByID(ctx context.Context, id string) (*model.User, error) {
@@ -56,7 +60,102 @@ func (r *UserRepository) FindByID(ctx context.Context, id string) (*model.User,
 }
 
 func (r *UserRepository) FindAll(ctx context.Context) ([]*model.User, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+	result, err := r.List(ctx, ListOptions{PageSize: MaxPageSize})
+	if err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// List returns a page of users matching opts, computing the page's items
+// and the total matching count in a single aggregation round-trip.
+func (r *UserRepository) List(ctx context.Context, opts ListOptions) (*PageResult[*model.User], error) {
+	opts = opts.withDefaults()
+
+	filter := opts.Filter
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	sortDir := 1
+	if opts.SortDesc {
+		sortDir = -1
+	}
+	skip := (opts.Page - 1) * opts.PageSize
+
+	dataStages := bson.A{
+		bson.M{"$sort": bson.M{opts.SortBy: sortDir}},
+		bson.M{"$skip": skip},
+		bson.M{"$limit": opts.PageSize},
+	}
+	if len(opts.Fields) > 0 {
+		projection := bson.M{}
+		for _, field := range opts.Fields {
+			projection[field] = 1
+		}
+		dataStages = append(dataStages, bson.M{"$project": projection})
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": filter},
+		bson.M{"$facet": bson.M{
+			"data":  dataStages,
+			"count": bson.A{bson.M{"$count": "total"}},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var facetResults []struct {
+		Data  []*model.User `bson:"data"`
+		Count []struct {
+			Total int64 `bson:"total"`
+		} `bson:"count"`
+	}
+	if err := cursor.All(ctx, &facetResults); err != nil {
+		return nil, err
+	}
+
+	var items []*model.User
+	var total int64
+	if len(facetResults) > 0 {
+		items = facetResults[0].Data
+		if len(facetResults[0].Count) > 0 {
+			total = facetResults[0].Count[0].Total
+		}
+	}
+
+	return &PageResult[*model.User]{
+		Items:      items,
+		TotalCount: total,
+		Page:       opts.Page,
+		HasNext:    int64(skip+len(items)) < total,
+	}, nil
+}
+
+// ListAfter returns up to limit users with _id greater than lastID, ordered
+// by _id ascending, for stable cursor-based scrolling through large result
+// sets. An empty lastID starts from the beginning.
+func (r *UserRepository) ListAfter(ctx context.Context, lastID string, limit int) ([]*model.User, error) {
+	if limit <= 0 || limit > MaxPageSize {
+		limit = DefaultPageSize
+	}
+
+	filter := bson.M{}
+	if lastID != "" {
+		oid, err := primitive.ObjectIDFromHex(lastID)
+		if err != nil {
+			return nil, errors.New("invalid cursor ID")
+		}
+		filter["_id"] = bson.M{"$gt": oid}
+	}
+
+	cursor, err := r.collection.Find(ctx, filter,
+		options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(limit)))
 	if err != nil {
 		return nil, err
 	}
@@ -107,16 +206,18 @@ func (r *UserRepository) Update(ctx context.Context, id string, user *model.User
 		},
 	}
 
-	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": oid}, update)
-	if err != nil {
-		return err
-	}
+	return retry.DoWithPolicy(ctx, retry.DefaultPolicy(), func() error {
+		result, err := r.collection.UpdateOne(ctx, bson.M{"_id": oid}, update)
+		if err != nil {
+			return err
+		}
 
-	if result.MatchedCount == 0 {
-		return errors.New("user not found")
-	}
+		if result.MatchedCount == 0 {
+			return errors.New("user not found")
+		}
 
-	return nil
+		return nil
+	}, retry.MongoClassifier)
 }
 
 func (r *UserRepository) Delete(ctx context.Context, id string) error {
@@ -125,31 +226,26 @@ func (r *UserRepository) Delete(ctx context.Context, id string) error {
 		return errors.New("invalid user ID")
 	}
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": oid})
-	if err != nil {
-		return err
-	}
+	return retry.DoWithPolicy(ctx, retry.DefaultPolicy(), func() error {
+		result, err := r.collection.DeleteOne(ctx, bson.M{"_id": oid})
+		if err != nil {
+			return err
+		}
 
-	if result.DeletedCount == 0 {
-		return errors.New("user not found")
-	}
+		if result.DeletedCount == 0 {
+			return errors.New("user not found")
+		}
 
-	return nil
+		return nil
+	}, retry.MongoClassifier)
 }
 
 func (r *UserRepository) FindByStatus(ctx context.Context, status string) ([]*model.User, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{"status": status})
+	result, err := r.List(ctx, ListOptions{PageSize: MaxPageSize, Filter: bson.M{"status": status}})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
-
-	var users []*model.User
-	if err = cursor.All(ctx, &users); err != nil {
-		return nil, err
-	}
-
-	return users, nil
+	return result.Items, nil
 }
 
 func (r *UserRepository) CountByStatus(ctx context.Context, status string) (int64, error) {
@@ -0,0 +1,40 @@
+package retry
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+const (
+	labelTransientTransactionError = "TransientTransactionError"
+	labelRetryableWriteError       = "RetryableWriteError"
+)
+
+// MongoClassifier reports whether err is safe to retry: a transient
+// transaction or retryable write error label, or a server selection
+// timeout, all of which are commonly transient symptoms of a primary
+// failover rather than a permanent failure.
+func MongoClassifier(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.HasErrorLabel(labelTransientTransactionError) ||
+			cmdErr.HasErrorLabel(labelRetryableWriteError)
+	}
+
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, label := range writeErr.Labels {
+			if label == labelTransientTransactionError || label == labelRetryableWriteError {
+				return true
+			}
+		}
+	}
+
+	return errors.Is(err, topology.ErrServerSelectionTimeout)
+}
@@ -0,0 +1,129 @@
+// Package retry provides context-aware retry helpers with configurable
+// exponential backoff and jitter.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Jitter selects the backoff jitter strategy, per AWS's "Exponential Backoff
+// and Jitter" technique.
+type Jitter int
+
+const (
+	// JitterNone applies no jitter; delay grows deterministically.
+	JitterNone Jitter = iota
+	// JitterFull picks a random delay in [0, delay).
+	JitterFull
+	// JitterEqual picks a random delay in [delay/2, delay).
+	JitterEqual
+	// JitterDecorrelated derives each delay from the previous one:
+	// sleep = min(MaxDelay, base + rand[0, base*3)), i.e. in [base, base*4),
+	// with base carried forward as the previous sleep.
+	JitterDecorrelated
+)
+
+// Policy configures DoWithPolicy's backoff behavior.
+type Policy struct {
+	// BaseDelay is the initial delay, and the seed for decorrelated jitter.
+	BaseDelay time.Duration
+	// MaxDelay caps any computed delay.
+	MaxDelay time.Duration
+	// Multiplier is the exponential growth factor for None/Full/Equal jitter.
+	Multiplier float64
+	// Jitter selects the jitter strategy.
+	Jitter Jitter
+	// MaxElapsed bounds the total time spent retrying. Zero means no limit.
+	MaxElapsed time.Duration
+}
+
+// DefaultPolicy returns a Policy suited to retrying MongoDB operations
+// across a primary failover (typically a few seconds of election time).
+func DefaultPolicy() Policy {
+	return Policy{
+		BaseDelay:  50 * time.Millisecond,
+		MaxDelay:   2 * time.Second,
+		Multiplier: 2.0,
+		Jitter:     JitterDecorrelated,
+		MaxElapsed: 10 * time.Second,
+	}
+}
+
+// DoWithPolicy executes fn, retrying per policy whenever classifier reports
+// the returned error as retryable. It honors ctx.Done() between attempts and
+// returns ctx.Err() if the context is canceled while waiting.
+func DoWithPolicy(ctx context.Context, policy Policy, fn func() error, classifier func(error) bool) error {
+	start := time.Now()
+	delay := policy.BaseDelay
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if !classifier(err) {
+			return err
+		}
+		if policy.MaxElapsed > 0 && time.Since(start) >= policy.MaxElapsed {
+			return err
+		}
+
+		sleep := nextDelay(policy, delay, attempt)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+		delay = sleep
+	}
+}
+
+// nextDelay computes the delay for the given attempt. prevSleep is the delay
+// used by the previous attempt (or policy.BaseDelay for the first one), and
+// is only consulted by JitterDecorrelated.
+func nextDelay(policy Policy, prevSleep time.Duration, attempt int) time.Duration {
+	if policy.Jitter == JitterDecorrelated {
+		base := prevSleep
+		if base <= 0 {
+			base = policy.BaseDelay
+		}
+		sleep := time.Duration(rand.Int63n(int64(base)*3)) + base
+		return capDelay(sleep, policy.MaxDelay)
+	}
+
+	exp := float64(policy.BaseDelay) * pow(policy.Multiplier, attempt)
+	expDelay := capDelay(time.Duration(exp), policy.MaxDelay)
+
+	switch policy.Jitter {
+	case JitterFull:
+		if expDelay <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(expDelay)))
+	case JitterEqual:
+		half := expDelay / 2
+		if half <= 0 {
+			return expDelay
+		}
+		return half + time.Duration(rand.Int63n(int64(half)))
+	default:
+		return expDelay
+	}
+}
+
+func capDelay(d, max time.Duration) time.Duration {
+	if max > 0 && d > max {
+		return max
+	}
+	return d
+}
+
+func pow(base float64, exp int) float64 {
+	result := 1.0
+	for i := 0; i < exp; i++ {
+		result *= base
+	}
+	return result
+}
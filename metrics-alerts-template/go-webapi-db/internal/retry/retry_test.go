@@ -0,0 +1,76 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoWithPolicy_SucceedsAfterRetries(t *testing.T) {
+	attempts := 0
+	err := DoWithPolicy(context.Background(), Policy{
+		BaseDelay:  time.Millisecond,
+		MaxDelay:   10 * time.Millisecond,
+		Multiplier: 2,
+		Jitter:     JitterNone,
+	}, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}, func(error) bool { return true })
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDoWithPolicy_StopsWhenNotRetryable(t *testing.T) {
+	attempts := 0
+	wantErr := errors.New("permanent")
+
+	err := DoWithPolicy(context.Background(), DefaultPolicy(), func() error {
+		attempts++
+		return wantErr
+	}, func(error) bool { return false })
+
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestDoWithPolicy_HonorsContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := DoWithPolicy(ctx, Policy{
+		BaseDelay:  10 * time.Millisecond,
+		MaxDelay:   time.Second,
+		Multiplier: 2,
+		Jitter:     JitterNone,
+	}, func() error {
+		return errors.New("transient")
+	}, func(error) bool { return true })
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNextDelay_DecorrelatedCapsAtMaxDelay(t *testing.T) {
+	policy := Policy{BaseDelay: time.Second, MaxDelay: 2 * time.Second, Jitter: JitterDecorrelated}
+	for i := 0; i < 50; i++ {
+		d := nextDelay(policy, 5*time.Second, i)
+		if d > policy.MaxDelay {
+			t.Fatalf("expected delay capped at %v, got %v", policy.MaxDelay, d)
+		}
+	}
+}
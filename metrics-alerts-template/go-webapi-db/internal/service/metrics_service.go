@@ -1,126 +1,247 @@
 package service
 
 import (
+	"context"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"go-webapi-db/internal/breaker"
+	"go-webapi-db/internal/metrics"
+)
+
+const (
+	defaultApplication = "go-webapi-db"
+
+	// maxDistinctLabelValues bounds how many distinct values a
+	// cardinality-sensitive label (e.g. error_type) may take before
+	// further values collapse into "other", so a bug or abuse upstream
+	// can't blow up a metric's series count.
+	maxDistinctLabelValues = 50
 )
 
+// Options configures a MetricsService. A nil Registry gets its own private
+// *prometheus.Registry, so multiple MetricsService instances (one per test,
+// or one per service sharing a process) can coexist without panicking on
+// duplicate registration against the global default registry. Registry is
+// ignored if Exporter is set.
+//
+// Exporter lets a caller target a non-Prometheus backend (or several, via
+// metrics.NewMultiExporter); when nil, NewMetricsService defaults to a
+// metrics.PrometheusExporter wrapping Registry, preserving the previous
+// behavior.
+// ActiveUsers is optional; when set, RecordUserActive forwards to it so the
+// user_active_last_hour gauge reflects real UserService traffic. Nil-safe
+// for callers (e.g. most tests) that don't care about the active-user SLI.
+type Options struct {
+	Registry    *prometheus.Registry
+	Exporter    metrics.Exporter
+	Application string
+	ActiveUsers *metrics.ActiveUsersCollector
+}
+
 type MetricsService struct {
-	userCreatedCounter         *prometheus.CounterVec
-	userUpdatedCounter         *prometheus.CounterVec
-	userDeletedCounter         *prometheus.CounterVec
-	userOperationDuration      *prometheus.HistogramVec
-	userActiveOperations       prometheus.Gauge
-	userOperationErrorsCounter *prometheus.CounterVec
-	externalCallDuration       *prometheus.HistogramVec
-	externalCallErrorsCounter  *prometheus.CounterVec
-	activeOperationsCount      int64
-	mu                         sync.Mutex
-}
-
-func NewMetricsService() *MetricsService {
+	application string
+	exporter    metrics.Exporter
+	activeUsers *metrics.ActiveUsersCollector
+
+	activeOperationsCount atomic.Int64
+	errorTypes            *boundedLabelSet
+}
+
+func NewMetricsService(opts Options) *MetricsService {
+	application := opts.Application
+	if application == "" {
+		application = defaultApplication
+	}
+
+	exporter := opts.Exporter
+	if exporter == nil {
+		registry := opts.Registry
+		if registry == nil {
+			registry = prometheus.NewRegistry()
+		}
+		exporter = metrics.NewPrometheusExporter(registry)
+	}
+
 	return &MetricsService{
-		userCreatedCounter: promauto.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "custom_user_created_total",
-				Help: "Total number of users created",
-			},
-			[]string{"application", "operation"},
-		),
-		userUpdatedCounter: promauto.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "custom_user_updated_total",
-				Help: "Total number of users updated",
-			},
-			[]string{"application", "operation"},
-		),
-		userDeletedCounter: promauto.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "custom_user_deleted_total",
-				Help: "Total number of users deleted",
-			},
-			[]string{"application", "operation"},
-		),
-		userOperationDuration: promauto.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "custom_user_operation_duration_seconds",
-				Help:    "Duration of user operations in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"application"},
-		),
-		userActiveOperations: promauto.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "custom_user_active_operations",
-				Help: "Number of active user operations",
-			},
-		),
-		userOperationErrorsCounter: promauto.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "custom_user_operation_errors_total",
-				Help: "Total number of user operation errors",
-			},
-			[]string{"application", "error_type"},
-		),
-		externalCallDuration: promauto.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "custom_external_call_duration_seconds",
-				Help:    "Duration of external service calls in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"application", "service"},
-		),
-		externalCallErrorsCounter: promauto.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "custom_external_call_errors_total",
-				Help: "Total number of external service call failures",
-			},
-			[]string{"application", "service"},
-		),
+		application: application,
+		exporter:    exporter,
+		activeUsers: opts.ActiveUsers,
+		errorTypes:  newBoundedLabelSet(maxDistinctLabelValues),
+	}
+}
+
+// RecordUserActive marks userID as having just performed a user operation,
+// so it counts toward user_active_last_hour until the configured
+// active-user window elapses. A no-op when Options.ActiveUsers wasn't set.
+func (m *MetricsService) RecordUserActive(userID string) {
+	if m.activeUsers != nil {
+		m.activeUsers.RecordSeen(userID)
 	}
 }
 
 func (m *MetricsService) IncrementUserCreated() {
-	m.userCreatedCounter.WithLabelValues("go-webapi-db", "create").Inc()
+	m.exporter.IncrCounter("custom_user_created_total", 1, "application", m.application, "operation", "create")
 }
 
 func (m *MetricsService) IncrementUserUpdated() {
-	m.userUpdatedCounter.WithLabelValues("go-webapi-db", "update").Inc()
+	m.exporter.IncrCounter("custom_user_updated_total", 1, "application", m.application, "operation", "update")
 }
 
 func (m *MetricsService) IncrementUserDeleted() {
-	m.userDeletedCounter.WithLabelValues("go-webapi-db", "delete").Inc()
+	m.exporter.IncrCounter("custom_user_deleted_total", 1, "application", m.application, "operation", "delete")
 }
 
 func (m *MetricsService) IncrementUserOperationErrors(errorType string) {
-	m.userOperationErrorsCounter.WithLabelValues("go-webapi-db", errorType).Inc()
+	m.exporter.IncrCounter("custom_user_operation_errors_total", 1, "application", m.application, "error_type", m.errorTypes.Resolve(errorType))
 }
 
 func (m *MetricsService) StartUserOperationTimer() func() {
-	m.mu.Lock()
-	m.activeOperationsCount++
-	m.userActiveOperations.Set(float64(m.activeOperationsCount))
-	m.mu.Unlock()
+	active := m.activeOperationsCount.Add(1)
+	m.exporter.SetGauge("custom_user_active_operations", float64(active))
 
 	start := time.Now()
 	return func() {
 		duration := time.Since(start).Seconds()
-		m.userOperationDuration.WithLabelValues("go-webapi-db").Observe(duration)
+		m.exporter.ObserveHistogram("custom_user_operation_duration_seconds", duration, "application", m.application)
+
+		active := m.activeOperationsCount.Add(-1)
+		m.exporter.SetGauge("custom_user_active_operations", float64(active))
+	}
+}
 
-		m.mu.Lock()
-		m.activeOperationsCount--
-		m.userActiveOperations.Set(float64(m.activeOperationsCount))
-		m.mu.Unlock()
+// RecordExternalCallDuration observes the call's duration through the
+// active exporter and, if ctx carries an active span, records it as a span
+// event too, so external-call latency can be correlated with traces and
+// not just with metrics.
+func (m *MetricsService) RecordExternalCallDuration(ctx context.Context, service string, duration time.Duration) {
+	m.observeHistogram(ctx, "custom_external_call_duration_seconds", duration.Seconds(), "application", m.application, "service", service)
+
+	span := oteltrace.SpanFromContext(ctx)
+	span.AddEvent("external_call.duration", oteltrace.WithAttributes(
+		attribute.String("service.name", service),
+		attribute.Float64("duration_ms", float64(duration.Microseconds())/1000),
+	))
+}
+
+// observeHistogram records value for the named histogram, attaching a
+// trace_id/span_id exemplar when ctx carries a valid OpenTelemetry span and
+// the active exporter supports exemplars (metrics.ExemplarObserver) -
+// currently only PrometheusExporter, since OpenMetrics exemplars are a
+// Prometheus-specific exposition format extension.
+func (m *MetricsService) observeHistogram(ctx context.Context, name string, value float64, labels ...string) {
+	if exemplarLabels := exemplarLabelsFromContext(ctx); exemplarLabels != nil {
+		if observer, ok := m.exporter.(metrics.ExemplarObserver); ok {
+			observer.ObserveHistogramWithExemplar(name, value, exemplarLabels, labels...)
+			return
+		}
 	}
+	m.exporter.ObserveHistogram(name, value, labels...)
 }
 
-func (m *MetricsService) RecordExternalCallDuration(service string, duration time.Duration) {
-	m.externalCallDuration.WithLabelValues("go-webapi-db", service).Observe(duration.Seconds())
+// exemplarLabelsFromContext returns a trace_id/span_id exemplar label set
+// for ctx's active span, or nil if ctx carries no valid span context.
+func exemplarLabelsFromContext(ctx context.Context) map[string]string {
+	spanCtx := oteltrace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return nil
+	}
+	return map[string]string{
+		"trace_id": spanCtx.TraceID().String(),
+		"span_id":  spanCtx.SpanID().String(),
+	}
 }
 
 func (m *MetricsService) IncrementExternalCallErrors(service string) {
-	m.externalCallErrorsCounter.WithLabelValues("go-webapi-db", service).Inc()
+	m.exporter.IncrCounter("custom_external_call_errors_total", 1, "application", m.application, "service", service)
+}
+
+// IncrementExternalCallShortCircuited records a call that was rejected
+// without reaching the external service because its breaker was open.
+func (m *MetricsService) IncrementExternalCallShortCircuited(service string) {
+	m.exporter.IncrCounter("custom_external_call_short_circuited_total", 1, "application", m.application, "service", service)
+}
+
+// SetBreakerState publishes service's current breaker state so it can be
+// alerted on (e.g. paging when a breaker stays Open past some duration).
+func (m *MetricsService) SetBreakerState(service string, state breaker.State) {
+	m.exporter.SetGauge("custom_external_call_breaker_state", float64(state), "service", service)
+}
+
+// RecordHTTPRequest observes one HTTP request's outcome, so middleware can
+// report request metrics through MetricsService instead of a separate
+// ad hoc collector.
+func (m *MetricsService) RecordHTTPRequest(endpoint, method string, status int, d time.Duration) {
+	statusCode := strconv.Itoa(status)
+	m.exporter.IncrCounter("custom_http_requests_total", 1, "application", m.application, "endpoint", endpoint, "method", method, "status_code", statusCode)
+	m.exporter.ObserveHistogram("custom_http_request_duration_seconds", d.Seconds(), "application", m.application, "endpoint", endpoint, "method", method, "status_code", statusCode)
+}
+
+// RecordHTTPRequestCtx is RecordHTTPRequest plus a trace/span exemplar on
+// the duration histogram when ctx carries an active span, so a latency
+// spike in Grafana can jump straight to the request's trace.
+func (m *MetricsService) RecordHTTPRequestCtx(ctx context.Context, endpoint, method string, status int, d time.Duration) {
+	statusCode := strconv.Itoa(status)
+	m.exporter.IncrCounter("custom_http_requests_total", 1, "application", m.application, "endpoint", endpoint, "method", method, "status_code", statusCode)
+	m.observeHistogram(ctx, "custom_http_request_duration_seconds", d.Seconds(), "application", m.application, "endpoint", endpoint, "method", method, "status_code", statusCode)
+}
+
+// boundedLabelSet caps the number of distinct values a label may take: once
+// max distinct values have been seen, any new value is folded into "other"
+// so a bug or abuse upstream can't produce unbounded series cardinality.
+type boundedLabelSet struct {
+	mu   sync.Mutex
+	max  int
+	seen map[string]struct{}
+}
+
+func newBoundedLabelSet(max int) *boundedLabelSet {
+	return &boundedLabelSet{max: max, seen: make(map[string]struct{})}
+}
+
+// Resolve returns value if it's safe to use as a label value, "invalid" if
+// value looks like a raw identifier (a Mongo ObjectID or a numeric ID) that
+// should never have been used as a label, or "other" once max distinct
+// values have already been seen.
+func (s *boundedLabelSet) Resolve(value string) string {
+	if looksLikeRawID(value) {
+		return "invalid"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[value]; ok {
+		return value
+	}
+	if len(s.seen) >= s.max {
+		return "other"
+	}
+	s.seen[value] = struct{}{}
+	return value
+}
+
+// looksLikeRawID reports whether value resembles a raw identifier (a 24-hex
+// Mongo ObjectID or a plain numeric ID) rather than a bounded enum value.
+func looksLikeRawID(value string) bool {
+	if len(value) == 24 && isHex(value) {
+		return true
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil && len(value) > 3 {
+		return true
+	}
+	return false
+}
+
+func isHex(value string) bool {
+	return strings.IndexFunc(value, func(r rune) bool {
+		return !strings.ContainsRune("0123456789abcdefABCDEF", r)
+	}) == -1
 }
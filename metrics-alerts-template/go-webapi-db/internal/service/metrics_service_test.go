@@ -1,33 +1,23 @@
 package service
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"go-webapi-db/internal/metrics"
 )
 
 func TestMetricsService_UserCreatedCounter(t *testing.T) {
-	// Create a new registry for testing
 	reg := prometheus.NewRegistry()
-	
-	// Create metrics service with custom registry
-	ms := &MetricsService{
-		userCreatedCounter: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "custom_user_created_total",
-				Help: "Total number of users created",
-			},
-			[]string{"application", "operation"},
-		),
-	}
-	reg.MustRegister(ms.userCreatedCounter)
-
-	// Test increment
+	ms := NewMetricsService(Options{Registry: reg})
+
 	ms.IncrementUserCreated()
 	ms.IncrementUserCreated()
 
-	// Verify metric exists and has correct value
 	metrics, err := reg.Gather()
 	if err != nil {
 		t.Fatalf("Failed to gather metrics: %v", err)
@@ -54,16 +44,7 @@ func TestMetricsService_UserCreatedCounter(t *testing.T) {
 
 func TestMetricsService_UserUpdatedCounter(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	ms := &MetricsService{
-		userUpdatedCounter: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "custom_user_updated_total",
-				Help: "Total number of users updated",
-			},
-			[]string{"application", "operation"},
-		),
-	}
-	reg.MustRegister(ms.userUpdatedCounter)
+	ms := NewMetricsService(Options{Registry: reg})
 
 	ms.IncrementUserUpdated()
 
@@ -86,16 +67,7 @@ func TestMetricsService_UserUpdatedCounter(t *testing.T) {
 
 func TestMetricsService_UserDeletedCounter(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	ms := &MetricsService{
-		userDeletedCounter: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "custom_user_deleted_total",
-				Help: "Total number of users deleted",
-			},
-			[]string{"application", "operation"},
-		),
-	}
-	reg.MustRegister(ms.userDeletedCounter)
+	ms := NewMetricsService(Options{Registry: reg})
 
 	ms.IncrementUserDeleted()
 
@@ -118,17 +90,7 @@ func TestMetricsService_UserDeletedCounter(t *testing.T) {
 
 func TestMetricsService_UserOperationDuration(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	ms := &MetricsService{
-		userOperationDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "custom_user_operation_duration_seconds",
-				Help:    "Duration of user operations in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"application"},
-		),
-	}
-	reg.MustRegister(ms.userOperationDuration)
+	ms := NewMetricsService(Options{Registry: reg})
 
 	stopTimer := ms.StartUserOperationTimer()
 	time.Sleep(10 * time.Millisecond)
@@ -143,7 +105,6 @@ func TestMetricsService_UserOperationDuration(t *testing.T) {
 	for _, mf := range metrics {
 		if mf.GetName() == "custom_user_operation_duration_seconds" {
 			found = true
-			// Check that histogram has buckets
 			if len(mf.GetMetric()) == 0 {
 				t.Error("Histogram has no metrics")
 			}
@@ -157,22 +118,9 @@ func TestMetricsService_UserOperationDuration(t *testing.T) {
 
 func TestMetricsService_UserActiveOperations(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	ms := &MetricsService{
-		userActiveOperations: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "custom_user_active_operations",
-				Help: "Number of active user operations",
-			},
-		),
-		activeOperationsCount: 0,
-	}
-	reg.MustRegister(ms.userActiveOperations)
-
-	// Start operation
-	ms.mu.Lock()
-	ms.activeOperationsCount++
-	ms.userActiveOperations.Set(float64(ms.activeOperationsCount))
-	ms.mu.Unlock()
+	ms := NewMetricsService(Options{Registry: reg})
+
+	stopTimer := ms.StartUserOperationTimer()
 
 	metrics, err := reg.Gather()
 	if err != nil {
@@ -196,20 +144,13 @@ func TestMetricsService_UserActiveOperations(t *testing.T) {
 	if value != 1 {
 		t.Errorf("Expected gauge value 1, got %f", value)
 	}
+
+	stopTimer()
 }
 
 func TestMetricsService_UserOperationErrors(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	ms := &MetricsService{
-		userOperationErrorsCounter: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "custom_user_operation_errors_total",
-				Help: "Total number of user operation errors",
-			},
-			[]string{"application", "error_type"},
-		),
-	}
-	reg.MustRegister(ms.userOperationErrorsCounter)
+	ms := NewMetricsService(Options{Registry: reg})
 
 	ms.IncrementUserOperationErrors("not_found")
 	ms.IncrementUserOperationErrors("duplicate_email")
@@ -236,21 +177,58 @@ func TestMetricsService_UserOperationErrors(t *testing.T) {
 	}
 }
 
-func TestMetricsService_ExternalCallDuration(t *testing.T) {
+func TestMetricsService_UserOperationErrors_HighCardinalityValueIsRejected(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	ms := &MetricsService{
-		externalCallDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "custom_external_call_duration_seconds",
-				Help:    "Duration of external service calls in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"application", "service"},
-		),
+	ms := NewMetricsService(Options{Registry: reg})
+
+	ms.IncrementUserOperationErrors("507f1f77bcf86cd799439011") // looks like a Mongo ObjectID
+
+	metrics, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, mf := range metrics {
+		if mf.GetName() != "custom_user_operation_errors_total" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "error_type" && label.GetValue() != "invalid" {
+					t.Errorf("expected error_type to be folded to 'invalid', got %q", label.GetValue())
+				}
+			}
+		}
 	}
-	reg.MustRegister(ms.externalCallDuration)
+}
 
-	ms.RecordExternalCallDuration("test-service", 100*time.Millisecond)
+func TestMetricsService_UserOperationErrors_OverflowFoldsIntoOther(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	ms := NewMetricsService(Options{Registry: reg})
+
+	for i := 0; i < maxDistinctLabelValues+5; i++ {
+		ms.IncrementUserOperationErrors(string(rune('a'+i%26)) + string(rune('a'+i/26)) + "_error_type")
+	}
+
+	metrics, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, mf := range metrics {
+		if mf.GetName() == "custom_user_operation_errors_total" {
+			if len(mf.GetMetric()) > maxDistinctLabelValues+1 { // +1 for the "other" bucket
+				t.Errorf("expected at most %d distinct error_type series, got %d", maxDistinctLabelValues+1, len(mf.GetMetric()))
+			}
+		}
+	}
+}
+
+func TestMetricsService_ExternalCallDuration(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	ms := NewMetricsService(Options{Registry: reg})
+
+	ms.RecordExternalCallDuration(context.Background(), "test-service", 100*time.Millisecond)
 
 	metrics, err := reg.Gather()
 	if err != nil {
@@ -269,18 +247,53 @@ func TestMetricsService_ExternalCallDuration(t *testing.T) {
 	}
 }
 
-func TestMetricsService_ExternalCallErrors(t *testing.T) {
+func TestMetricsService_ExternalCallDuration_AttachesExemplarWhenSpanPresent(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	ms := &MetricsService{
-		externalCallErrorsCounter: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "custom_external_call_errors_total",
-				Help: "Total number of external service call failures",
-			},
-			[]string{"application", "service"},
-		),
+	ms := NewMetricsService(Options{Registry: reg})
+
+	traceID, _ := oteltrace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	spanID, _ := oteltrace.SpanIDFromHex("0102030405060708")
+	spanCtx := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: oteltrace.FlagsSampled,
+	})
+	ctx := oteltrace.ContextWithSpanContext(context.Background(), spanCtx)
+
+	ms.RecordExternalCallDuration(ctx, "test-service", 50*time.Millisecond)
+
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
 	}
-	reg.MustRegister(ms.externalCallErrorsCounter)
+
+	var found bool
+	for _, mf := range metricFamilies {
+		if mf.GetName() != "custom_external_call_duration_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, bucket := range m.GetHistogram().GetBucket() {
+				exemplar := bucket.GetExemplar()
+				if exemplar == nil {
+					continue
+				}
+				for _, label := range exemplar.GetLabel() {
+					if label.GetName() == "trace_id" && label.GetValue() == traceID.String() {
+						found = true
+					}
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("expected an exemplar with the span's trace_id on the duration histogram")
+	}
+}
+
+func TestMetricsService_ExternalCallErrors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	ms := NewMetricsService(Options{Registry: reg})
 
 	ms.IncrementExternalCallErrors("test-service")
 
@@ -301,19 +314,36 @@ func TestMetricsService_ExternalCallErrors(t *testing.T) {
 	}
 }
 
-func TestMetricsService_AllMetricsRegistered(t *testing.T) {
-	ms := NewMetricsService()
+func TestMetricsService_RecordHTTPRequest(t *testing.T) {
 	reg := prometheus.NewRegistry()
+	ms := NewMetricsService(Options{Registry: reg})
 
-	// Register all metrics
-	reg.MustRegister(ms.userCreatedCounter)
-	reg.MustRegister(ms.userUpdatedCounter)
-	reg.MustRegister(ms.userDeletedCounter)
-	reg.MustRegister(ms.userOperationDuration)
-	reg.MustRegister(ms.userActiveOperations)
-	reg.MustRegister(ms.userOperationErrorsCounter)
-	reg.MustRegister(ms.externalCallDuration)
-	reg.MustRegister(ms.externalCallErrorsCounter)
+	ms.RecordHTTPRequest("/api/users/:id", "GET", 200, 5*time.Millisecond)
+
+	metrics, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	expectedMetrics := map[string]bool{
+		"custom_http_requests_total":         false,
+		"custom_http_request_duration_seconds": false,
+	}
+	for _, mf := range metrics {
+		if _, ok := expectedMetrics[mf.GetName()]; ok {
+			expectedMetrics[mf.GetName()] = true
+		}
+	}
+	for name, found := range expectedMetrics {
+		if !found {
+			t.Errorf("Expected metric %s not found", name)
+		}
+	}
+}
+
+func TestMetricsService_AllMetricsRegistered(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	NewMetricsService(Options{Registry: reg})
 
 	metrics, err := reg.Gather()
 	if err != nil {
@@ -322,13 +352,17 @@ func TestMetricsService_AllMetricsRegistered(t *testing.T) {
 
 	expectedMetrics := map[string]bool{
 		"custom_user_created_total":              false,
-		"custom_user_updated_total":              false,
+		"custom_user_updated_total":               false,
 		"custom_user_deleted_total":               false,
 		"custom_user_operation_duration_seconds":  false,
-		"custom_user_active_operations":          false,
-		"custom_user_operation_errors_total":     false,
+		"custom_user_active_operations":           false,
+		"custom_user_operation_errors_total":      false,
 		"custom_external_call_duration_seconds":   false,
-		"custom_external_call_errors_total":      false,
+		"custom_external_call_errors_total":       false,
+		"custom_external_call_short_circuited_total": false,
+		"custom_external_call_breaker_state":      false,
+		"custom_http_requests_total":              false,
+		"custom_http_request_duration_seconds":    false,
 	}
 
 	for _, mf := range metrics {
@@ -345,10 +379,8 @@ func TestMetricsService_AllMetricsRegistered(t *testing.T) {
 }
 
 func TestMetricsService_StartUserOperationTimer(t *testing.T) {
-	ms := NewMetricsService()
 	reg := prometheus.NewRegistry()
-	reg.MustRegister(ms.userOperationDuration)
-	reg.MustRegister(ms.userActiveOperations)
+	ms := NewMetricsService(Options{Registry: reg})
 
 	// Start timer
 	stopTimer := ms.StartUserOperationTimer()
@@ -396,3 +428,47 @@ func TestMetricsService_StartUserOperationTimer(t *testing.T) {
 	}
 }
 
+func TestMetricsService_RecordUserActive_NoopWithoutActiveUsersCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	ms := NewMetricsService(Options{Registry: reg})
+
+	// Should not panic when Options.ActiveUsers wasn't set.
+	ms.RecordUserActive("user-1")
+}
+
+func TestMetricsService_RecordUserActive_ForwardsToActiveUsersCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	activeUsers := metrics.NewActiveUsersCollector(time.Hour)
+	ms := NewMetricsService(Options{Registry: reg, ActiveUsers: activeUsers})
+
+	ms.RecordUserActive("user-1")
+	ms.RecordUserActive("user-2")
+	ms.RecordUserActive("user-1")
+
+	if got := gatherUserActiveLastHourInPackage(t, activeUsers); got != 2 {
+		t.Errorf("expected 2 distinct active users, got %v", got)
+	}
+}
+
+// gatherUserActiveLastHourInPackage forces activeUsers to publish its
+// current count, then reads user_active_last_hour back from the default
+// gatherer, since that gauge is promauto-registered against
+// prometheus.DefaultRegisterer regardless of which collector wrote it.
+func gatherUserActiveLastHourInPackage(t *testing.T, activeUsers *metrics.ActiveUsersCollector) float64 {
+	t.Helper()
+	activeUsers.Start(time.Millisecond)
+	defer activeUsers.Stop()
+	time.Sleep(20 * time.Millisecond)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() == "user_active_last_hour" {
+			return mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatal("metric user_active_last_hour not found")
+	return 0
+}
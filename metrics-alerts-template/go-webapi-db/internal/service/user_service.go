@@ -2,29 +2,43 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"math/rand"
 	"time"
 
+	"go-webapi-db/internal/breaker"
 	"go-webapi-db/internal/model"
 	"go-webapi-db/internal/repository"
+	"go-webapi-db/internal/trace"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 type UserService struct {
-	repo          repository.UserRepositoryInterface
-	metrics       *MetricsService
-	random        *rand.Rand
+	repo     repository.UserRepositoryInterface
+	metrics  *MetricsService
+	breakers *breaker.Registry
+	random   *rand.Rand
 }
 
-func NewUserService(repo repository.UserRepositoryInterface, metrics *MetricsService) *UserService {
+func NewUserService(repo repository.UserRepositoryInterface, metrics *MetricsService, breakers *breaker.Registry) *UserService {
 	return &UserService{
-		repo:    repo,
-		metrics: metrics,
-		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		repo:     repo,
+		metrics:  metrics,
+		breakers: breakers,
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
-func (s *UserService) CreateUser(ctx context.Context, req *model.CreateUserRequest) (*model.User, error) {
+func (s *UserService) CreateUser(ctx context.Context, req *model.CreateUserRequest) (user *model.User, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "user.create")
+	span.SetAttributes(attribute.String("user.email", hashEmail(req.Email)))
+	defer func() { endSpan(span, err) }()
+
 	stopTimer := s.metrics.StartUserOperationTimer()
 	defer stopTimer()
 
@@ -36,13 +50,14 @@ func (s *UserService) CreateUser(ctx context.Context, req *model.CreateUserReque
 	}
 	if exists {
 		s.metrics.IncrementUserOperationErrors("duplicate_email")
-		return nil, errors.New("user with email " + req.Email + " already exists")
+		err = errors.New("user with email " + req.Email + " already exists")
+		return nil, err
 	}
 
 	// Simulate occasional slow operations
 	s.simulateRandomDelay()
 
-	user := &model.User{
+	user = &model.User{
 		Email:  req.Email,
 		Name:   req.Name,
 		Status: "ACTIVE",
@@ -58,11 +73,17 @@ func (s *UserService) CreateUser(ctx context.Context, req *model.CreateUserReque
 		return nil, err
 	}
 
+	span.SetAttributes(attribute.String("user.id", user.ID.Hex()))
 	s.metrics.IncrementUserCreated()
+	s.metrics.RecordUserActive(user.ID.Hex())
 	return user, nil
 }
 
-func (s *UserService) GetUserByID(ctx context.Context, id string) (*model.User, error) {
+func (s *UserService) GetUserByID(ctx context.Context, id string) (user *model.User, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "user.get_by_id")
+	span.SetAttributes(attribute.String("user.id", id))
+	defer func() { endSpan(span, err) }()
+
 	stopTimer := s.metrics.StartUserOperationTimer()
 	defer stopTimer()
 
@@ -71,7 +92,7 @@ func (s *UserService) GetUserByID(ctx context.Context, id string) (*model.User,
 		time.Sleep(3 * time.Second)
 	}
 
-	user, err := s.repo.FindByID(ctx, id)
+	user, err = s.repo.FindByID(ctx, id)
 	if err != nil {
 		s.metrics.IncrementUserOperationErrors("timeout")
 		return nil, err
@@ -79,42 +100,58 @@ func (s *UserService) GetUserByID(ctx context.Context, id string) (*model.User,
 
 	if user == nil {
 		s.metrics.IncrementUserOperationErrors("not_found")
-		return nil, errors.New("user not found")
+		err = errors.New("user not found")
+		return nil, err
 	}
 
+	s.metrics.RecordUserActive(id)
 	return user, nil
 }
 
-func (s *UserService) GetAllUsers(ctx context.Context) ([]*model.User, error) {
+func (s *UserService) GetAllUsers(ctx context.Context) (users []*model.User, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "user.get_all")
+	defer func() { endSpan(span, err) }()
+
 	stopTimer := s.metrics.StartUserOperationTimer()
 	defer stopTimer()
 
 	s.simulateRandomDelay()
-	return s.repo.FindAll(ctx)
+	users, err = s.repo.FindAll(ctx)
+	return users, err
 }
 
-func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (user *model.User, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "user.get_by_email")
+	span.SetAttributes(attribute.String("user.email", hashEmail(email)))
+	defer func() { endSpan(span, err) }()
+
 	stopTimer := s.metrics.StartUserOperationTimer()
 	defer stopTimer()
 
-	user, err := s.repo.FindByEmail(ctx, email)
+	user, err = s.repo.FindByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 
 	if user == nil {
 		s.metrics.IncrementUserOperationErrors("not_found")
-		return nil, errors.New("user not found")
+		err = errors.New("user not found")
+		return nil, err
 	}
 
+	s.metrics.RecordUserActive(user.ID.Hex())
 	return user, nil
 }
 
-func (s *UserService) UpdateUser(ctx context.Context, id string, req *model.UpdateUserRequest) (*model.User, error) {
+func (s *UserService) UpdateUser(ctx context.Context, id string, req *model.UpdateUserRequest) (user *model.User, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "user.update")
+	span.SetAttributes(attribute.String("user.id", id))
+	defer func() { endSpan(span, err) }()
+
 	stopTimer := s.metrics.StartUserOperationTimer()
 	defer stopTimer()
 
-	user, err := s.repo.FindByID(ctx, id)
+	user, err = s.repo.FindByID(ctx, id)
 	if err != nil {
 		s.metrics.IncrementUserOperationErrors("update_failed")
 		return nil, err
@@ -122,13 +159,15 @@ func (s *UserService) UpdateUser(ctx context.Context, id string, req *model.Upda
 
 	if user == nil {
 		s.metrics.IncrementUserOperationErrors("not_found")
-		return nil, errors.New("user not found with id: " + id)
+		err = errors.New("user not found with id: " + id)
+		return nil, err
 	}
 
 	// Simulate occasional errors
 	if s.random.Intn(100) < 1 { // 1% chance
 		s.metrics.IncrementUserOperationErrors("update_failed")
-		return nil, errors.New("simulated database error")
+		err = errors.New("simulated database error")
+		return nil, err
 	}
 
 	if req.Name != "" {
@@ -136,6 +175,7 @@ func (s *UserService) UpdateUser(ctx context.Context, id string, req *model.Upda
 	}
 	if req.Email != "" {
 		user.Email = req.Email
+		span.SetAttributes(attribute.String("user.email", hashEmail(req.Email)))
 	}
 	if req.Status != "" {
 		user.Status = req.Status
@@ -154,10 +194,15 @@ func (s *UserService) UpdateUser(ctx context.Context, id string, req *model.Upda
 	}
 
 	s.metrics.IncrementUserUpdated()
+	s.metrics.RecordUserActive(id)
 	return updatedUser, nil
 }
 
-func (s *UserService) DeleteUser(ctx context.Context, id string) error {
+func (s *UserService) DeleteUser(ctx context.Context, id string) (err error) {
+	ctx, span := trace.Tracer().Start(ctx, "user.delete")
+	span.SetAttributes(attribute.String("user.id", id))
+	defer func() { endSpan(span, err) }()
+
 	stopTimer := s.metrics.StartUserOperationTimer()
 	defer stopTimer()
 
@@ -169,7 +214,8 @@ func (s *UserService) DeleteUser(ctx context.Context, id string) error {
 
 	if !exists {
 		s.metrics.IncrementUserOperationErrors("not_found")
-		return errors.New("user not found")
+		err = errors.New("user not found")
+		return err
 	}
 
 	err = s.repo.Delete(ctx, id)
@@ -179,26 +225,45 @@ func (s *UserService) DeleteUser(ctx context.Context, id string) error {
 	}
 
 	s.metrics.IncrementUserDeleted()
+	s.metrics.RecordUserActive(id)
 	return nil
 }
 
-func (s *UserService) GetUsersByStatus(ctx context.Context, status string) ([]*model.User, error) {
+func (s *UserService) GetUsersByStatus(ctx context.Context, status string) (users []*model.User, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "user.get_by_status")
+	defer func() { endSpan(span, err) }()
+
 	stopTimer := s.metrics.StartUserOperationTimer()
 	defer stopTimer()
 
-	return s.repo.FindByStatus(ctx, status)
+	users, err = s.repo.FindByStatus(ctx, status)
+	return users, err
 }
 
-func (s *UserService) CountUsersByStatus(ctx context.Context, status string) (int64, error) {
+func (s *UserService) CountUsersByStatus(ctx context.Context, status string) (count int64, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "user.count_by_status")
+	defer func() { endSpan(span, err) }()
+
 	stopTimer := s.metrics.StartUserOperationTimer()
 	defer stopTimer()
 
-	return s.repo.CountByStatus(ctx, status)
+	count, err = s.repo.CountByStatus(ctx, status)
+	return count, err
 }
 
-func (s *UserService) CallExternalService(ctx context.Context, serviceName string) (string, error) {
+func (s *UserService) CallExternalService(ctx context.Context, serviceName string) (result string, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "user.call_external")
+	span.SetAttributes(attribute.String("service.name", serviceName))
+	defer func() { endSpan(span, err) }()
+	defer func() { s.metrics.SetBreakerState(serviceName, s.breakers.State(serviceName)) }()
+
+	if err = s.breakers.Allow(serviceName); err != nil {
+		s.metrics.IncrementExternalCallShortCircuited(serviceName)
+		return "", err
+	}
+
 	start := time.Now()
-	
+
 	// Simulate external call
 	delay := time.Duration(s.random.Intn(500)+100) * time.Millisecond
 	time.Sleep(delay)
@@ -206,13 +271,16 @@ func (s *UserService) CallExternalService(ctx context.Context, serviceName strin
 	// Simulate occasional failures
 	if s.random.Intn(100) < 5 { // 5% failure rate
 		duration := time.Since(start)
-		s.metrics.RecordExternalCallDuration(serviceName, duration)
+		s.metrics.RecordExternalCallDuration(ctx, serviceName, duration)
 		s.metrics.IncrementExternalCallErrors(serviceName)
-		return "", errors.New("external service " + serviceName + " failed")
+		s.breakers.MarkFailure(serviceName)
+		err = errors.New("external service " + serviceName + " failed")
+		return "", err
 	}
 
 	duration := time.Since(start)
-	s.metrics.RecordExternalCallDuration(serviceName, duration)
+	s.metrics.RecordExternalCallDuration(ctx, serviceName, duration)
+	s.breakers.MarkSuccess(serviceName)
 	return "Success from " + serviceName, nil
 }
 
@@ -221,3 +289,23 @@ func (s *UserService) simulateRandomDelay() {
 	time.Sleep(delay)
 }
 
+// hashEmail returns a SHA-256 hex digest of email so span attributes can
+// correlate traces for the same user without leaking PII into the tracing
+// backend.
+func hashEmail(email string) string {
+	sum := sha256.Sum256([]byte(email))
+	return hex.EncodeToString(sum[:])
+}
+
+// endSpan records err (if any) as the span's outcome and status, then ends
+// it. Deferred with a named err return so every instrumented method reports
+// its real outcome regardless of which branch it returns from.
+func endSpan(span oteltrace.Span, err error) {
+	if err != nil {
+		span.SetAttributes(attribute.String("outcome", "error"))
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.String("outcome", "success"))
+	}
+	span.End()
+}
@@ -0,0 +1,125 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go-webapi-db/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/gin-gonic/gin"
+)
+
+var tracer = otel.Tracer("go-webapi-db")
+
+// Tracer returns the package-level tracer so service and repository code can
+// start spans without each constructing (and naming) their own, keeping a
+// single TracerProvider/instrumentation-name pair for the whole app.
+func Tracer() oteltrace.Tracer {
+	return tracer
+}
+
+// StartSpan starts a new span named name as a child of any span already
+// active in ctx, returning the span-carrying context and the span itself.
+// Callers must end the span (typically via defer span.End()).
+func StartSpan(ctx context.Context, name string) (context.Context, oteltrace.Span) {
+	return tracer.Start(ctx, name)
+}
+
+// InitTracer wires up an OTLP/HTTP exporter and registers it as the global
+// TracerProvider. If cfg.Endpoint is empty (the default), it no-ops and
+// returns a shutdown func that does nothing, so environments without a
+// collector (tests, local dev) keep working unmodified.
+func InitTracer(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpoint(cfg.Endpoint),
+		otlptracehttp.WithHeaders(cfg.Headers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tracing resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(cfg.SamplingRatio)),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
+}
+
+// Middleware extracts a W3C traceparent/tracestate from the incoming request
+// and starts a span for it, falling back to generating a trace ID and
+// propagating it via the legacy X-Trace-Id header when no traceparent is
+// present. Either way, the resulting trace ID is attached to the request
+// context via NewContext so existing FromContext callers (and the logger's
+// trace enrichment) keep working unchanged.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		var traceID string
+		if r.Header.Get("traceparent") != "" {
+			var span oteltrace.Span
+			ctx, span = tracer.Start(ctx, r.Method+" "+r.URL.Path)
+			defer span.End()
+			traceID = spanTraceID(ctx)
+		}
+		// A traceparent that yields no valid span (no propagator installed,
+		// or a malformed header) falls back the same way.
+		if traceID == "" {
+			traceID = r.Header.Get(HeaderName)
+			if traceID == "" {
+				traceID = New()
+			}
+		}
+
+		ctx = NewContext(ctx, traceID)
+		w.Header().Set(HeaderName, traceID)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GinMiddleware adapts Middleware for use with gin.Engine.Use, matching the
+// gin.HandlerFunc signature used by the rest of internal/middleware.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c.Request = r
+			c.Next()
+		})).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// spanTraceID returns the trace ID of the active OpenTelemetry span in ctx,
+// or an empty string if ctx carries no valid span context.
+func spanTraceID(ctx context.Context) string {
+	sc := oteltrace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
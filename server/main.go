@@ -3,20 +3,23 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
-	"github.com/google/uuid"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/yinghanhung/prr-playground/internal/logger"
+	"github.com/yinghanhung/prr-playground/internal/trace"
 )
 
 const logPath = "/var/log/app/app.log"
 
 type ctxKey string
 
-const traceKey ctxKey = "traceId"
+const loggerCtxKey ctxKey = "logger"
 
 type statusRecorder struct {
 	http.ResponseWriter
@@ -28,15 +31,6 @@ func (r *statusRecorder) WriteHeader(status int) {
 	r.ResponseWriter.WriteHeader(status)
 }
 
-type logEntry struct {
-	TraceID   string `json:"traceId"`
-	Method    string `json:"method"`
-	Path      string `json:"path"`
-	Status    int    `json:"status"`
-	LatencyMs int64  `json:"latencyMs"`
-	Message   string `json:"message"`
-}
-
 func ensureLogFile(path string) (*os.File, error) {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, err
@@ -44,97 +38,108 @@ func ensureLogFile(path string) (*os.File, error) {
 	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
 }
 
-func newLogger(path string) (*log.Logger, *os.File, *log.Logger, error) {
-	f, err := ensureLogFile(path)
-	if err != nil {
-		return nil, nil, nil, err
+func withLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey, l)
+}
+
+// loggerFromContext returns the request-scoped logger attached by
+// traceMiddleware, or slog.Default() outside a request (e.g. tests calling
+// a handler directly).
+func loggerFromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerCtxKey).(*slog.Logger); ok {
+		return l
 	}
-	// Write to stdout with timestamp for docker logs, file without timestamp for Fluent Bit parsing
-	stdoutLogger := log.New(os.Stdout, "", log.LstdFlags|log.LUTC)
-	fileLogger := log.New(f, "", 0) // No timestamp prefix for clean JSON
-	return stdoutLogger, f, fileLogger, nil
+	return slog.Default()
 }
 
-func traceMiddleware(stdoutLogger *log.Logger, fileLogger *log.Logger, next http.Handler) http.Handler {
+// traceMiddleware assigns each request a trace ID and builds a request-scoped
+// child logger carrying traceId/method/path via slog.With, so handlers and
+// the completion log below never have to attach those fields by hand.
+func traceMiddleware(log *slog.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		traceID := r.Header.Get("X-Trace-Id")
+
+		// Extract a W3C traceparent if the caller sent one and start a span
+		// for it, falling back to the legacy X-Trace-Id header (or a newly
+		// generated ID) so trace.FromContext keeps working either way.
+		ctx := trace.Extract(r.Context(), r.Header)
+		var traceID string
+		if r.Header.Get("traceparent") != "" {
+			var span oteltrace.Span
+			ctx, span = trace.StartSpan(ctx, r.Method+" "+r.URL.Path)
+			defer span.End()
+			traceID = trace.FromContext(ctx)
+		}
+		// A traceparent that yields no valid span (no propagator installed,
+		// or a malformed header) falls back the same way.
 		if traceID == "" {
-			traceID = uuid.NewString()
+			traceID = r.Header.Get(trace.HeaderName)
+			if traceID == "" {
+				traceID = trace.New()
+			}
 		}
 
-		ctx := context.WithValue(r.Context(), traceKey, traceID)
+		reqLog := log.With("traceId", traceID, "method", r.Method, "path", r.URL.Path)
+		ctx = withLogger(trace.NewContext(ctx, traceID), reqLog)
 		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		w.Header().Set(trace.HeaderName, traceID)
 
 		next.ServeHTTP(rec, r.WithContext(ctx))
 
-		latency := time.Since(start)
-		logJSON(stdoutLogger, fileLogger, logEntry{
-			TraceID:   traceID,
-			Method:    r.Method,
-			Path:      r.URL.Path,
-			Status:    rec.status,
-			LatencyMs: latency.Milliseconds(),
-			Message:   "request completed",
-		})
+		reqLog.Info("request completed", "status", rec.status, "latencyMs", time.Since(start).Milliseconds())
 	})
 }
 
-func logJSON(stdoutLogger *log.Logger, fileLogger *log.Logger, entry logEntry) {
-	b, err := json.Marshal(entry)
-	if err != nil {
-		stdoutLogger.Printf(`{"message":"failed to marshal log","error":"%v"}\n`, err)
-		fileLogger.Printf(`{"message":"failed to marshal log","error":"%v"}\n`, err)
-		return
-	}
-	// Write to stdout with timestamp, file without timestamp (pure JSON)
-	stdoutLogger.Println(string(b))
-	fileLogger.Printf("%s\n", string(b))
-}
-
-func handleHello(stdoutLogger *log.Logger, fileLogger *log.Logger) http.HandlerFunc {
+func handleHello() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		traceID, _ := r.Context().Value(traceKey).(string)
+		log := loggerFromContext(r.Context())
 		resp := map[string]string{
 			"message": "hello",
-			"traceId": traceID,
+			"traceId": trace.FromContext(r.Context()),
 			"path":    r.URL.Path,
 		}
 		time.Sleep(50 * time.Millisecond) // simulate work
 
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
-			logJSON(stdoutLogger, fileLogger, logEntry{
-				TraceID: traceID,
-				Method:  r.Method,
-				Path:    r.URL.Path,
-				Status:  http.StatusInternalServerError,
-				Message: "failed to encode response",
-			})
+			log.Error("failed to encode response", "error", err)
 			return
 		}
 
-		logJSON(stdoutLogger, fileLogger, logEntry{
-			TraceID: traceID,
-			Method:  r.Method,
-			Path:    r.URL.Path,
-			Status:  http.StatusOK,
-			Message: "handler finished",
-		})
+		log.Info("handler finished")
 	}
 }
 
 func main() {
-	stdoutLogger, file, fileLogger, err := newLogger(logPath)
+	file, err := ensureLogFile(logPath)
 	if err != nil {
-		log.Fatalf("cannot init logger: %v", err)
+		slog.Error("cannot init logger", "error", err)
+		os.Exit(1)
 	}
 	defer file.Close()
 
+	// Stdout (docker logs) and the log file (Fluent Bit) share one format,
+	// chosen by LOG_FORMAT and JSON by default.
+	baseLog := logger.NewFanOut(os.Stdout, file, logger.Options{Level: logger.LevelFromEnv(), Format: logger.FormatFromEnv()})
+	// A persistently failing dependency (e.g. a stuck client hammering
+	// /hello) shouldn't flood stdout/the log file with identical lines.
+	log := slog.New(logger.NewDedupHandler(baseLog.Handler(), time.Minute))
+
+	shutdownTracer, err := trace.InitTracer(context.Background())
+	if err != nil {
+		log.Error("failed to initialize tracer", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracer(context.Background()); err != nil {
+			log.Error("failed to shut down tracer", "error", err)
+		}
+	}()
+
 	mux := http.NewServeMux()
-	mux.Handle("/hello", handleHello(stdoutLogger, fileLogger))
+	mux.Handle("/hello", handleHello())
 
-	handler := traceMiddleware(stdoutLogger, fileLogger, mux)
+	handler := traceMiddleware(log, mux)
 
 	server := &http.Server{
 		Addr:         ":8080",
@@ -144,9 +149,9 @@ func main() {
 		IdleTimeout:  30 * time.Second,
 	}
 
-	stdoutLogger.Println(`{"message":"server starting","addr":":8080"}`)
-	fileLogger.Printf(`{"message":"server starting","addr":":8080"}\n`)
+	log.Info("server starting", "addr", ":8080")
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		stdoutLogger.Fatalf(`{"message":"server error","error":"%v"}`, err)
+		log.Error("server error", "error", err)
+		os.Exit(1)
 	}
 }
@@ -2,8 +2,7 @@
 package middleware
 
 import (
-	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -23,28 +22,10 @@ func (r *StatusRecorder) WriteHeader(status int) {
 	r.ResponseWriter.WriteHeader(status)
 }
 
-type logEntry struct {
-	TraceID   string `json:"traceId"`
-	Method    string `json:"method"`
-	Path      string `json:"path"`
-	Status    int    `json:"status"`
-	LatencyMs int64  `json:"latencyMs"`
-	Message   string `json:"message"`
-}
-
-func logJSON(stdoutLogger *log.Logger, fileLogger *log.Logger, entry logEntry) {
-	b, err := json.Marshal(entry)
-	if err != nil {
-		stdoutLogger.Printf(`{"message":"failed to marshal log","error":"%v"}\n`, err)
-		fileLogger.Printf(`{"message":"failed to marshal log","error":"%v"}\n`, err)
-		return
-	}
-	stdoutLogger.Println(string(b))
-	fileLogger.Printf("%s\n", string(b))
-}
-
-// Trace returns middleware that adds trace ID to requests and logs them.
-func Trace(stdoutLogger *log.Logger, fileLogger *log.Logger, collector *metrics.Collector, next http.Handler) http.Handler {
+// Trace returns middleware that adds a trace ID to the request context,
+// records request metrics, and logs the completed request via a
+// request-scoped child logger carrying traceId/method/path/status/latencyMs.
+func Trace(log *slog.Logger, collector *metrics.Collector, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
@@ -70,13 +51,11 @@ func Trace(stdoutLogger *log.Logger, fileLogger *log.Logger, collector *metrics.
 		collector.RecordLatency(latency)
 
 		// Log request
-		logJSON(stdoutLogger, fileLogger, logEntry{
-			TraceID:   traceID,
-			Method:    r.Method,
-			Path:      r.URL.Path,
-			Status:    rec.Status,
-			LatencyMs: latency.Milliseconds(),
-			Message:   "request completed",
-		})
+		log.With("traceId", traceID).Info("request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.Status,
+			"latencyMs", latency.Milliseconds(),
+		)
 	})
 }